@@ -1,12 +1,38 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"InstanceTypAZCheck/ec2handler"
+	"InstanceTypAZCheck/ekshandler"
+	"InstanceTypAZCheck/elasticachehandler"
+	"InstanceTypAZCheck/rdshandler"
 	"github.com/aws/aws-lambda-go/cfn"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // main - entry point for the lambda function
 func main() {
-	lambda.Start(cfn.LambdaWrap(ec2handler.InstanceTypAZCheck))
+	lambda.Start(cfn.LambdaWrap(handler))
+}
+
+// handler - route the custom resource to the check for its Mode (EC2 by default)
+func handler(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
+	mode, _ := event.ResourceProperties["Mode"].(string)
+	if event.RequestType == cfn.RequestDelete {
+		return ec2handler.InstanceTypAZCheck(ctx, event)
+	}
+	switch mode {
+	case "", ec2handler.ModeEC2:
+		return ec2handler.InstanceTypAZCheck(ctx, event)
+	case rdshandler.ModeRDS, rdshandler.ModeEC2RDS:
+		return rdshandler.InstanceTypAZCheck(ctx, event)
+	case elasticachehandler.ModeElastiCache:
+		return elasticachehandler.InstanceTypAZCheck(ctx, event)
+	case ekshandler.ModeEKSNodeGroup:
+		return ekshandler.InstanceTypAZCheck(ctx, event)
+	}
+	return "", nil, fmt.Errorf("unsupported Mode %q (use %s, %s, %s, %s or %s)", mode, ec2handler.ModeEC2, rdshandler.ModeRDS,
+		rdshandler.ModeEC2RDS, elasticachehandler.ModeElastiCache, ekshandler.ModeEKSNodeGroup)
 }
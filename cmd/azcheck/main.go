@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// usage - print the available commands
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  matrix    instance types x availability zones for a region\n")
+	fmt.Fprintf(os.Stderr, "  asg       mixed instances policy overrides x the zones of an Auto Scaling group\n")
+}
+
+// main - command line entry point for running the checks outside of CloudFormation
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "matrix":
+		err = runMatrix(os.Args[2:])
+	case "asg":
+		err = runASG(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runMatrix - print the availability matrix for the instance type patterns
+func runMatrix(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	typesFlag := fs.String("types", "", "comma separated instance type patterns, e.g. t4g.*,m7*.large")
+	format := fs.String("format", ec2handler.MatrixFormatTable, "output format: table, csv, json or markdown")
+	region := fs.String("region", "", "region to report on (defaults to the configured region)")
+	verbose := fs.Bool("v", false, "log the AWS calls")
+	_ = fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	patterns := splitList(*typesFlag)
+	if len(patterns) == 0 {
+		fs.Usage()
+		return fmt.Errorf("-types is required")
+	}
+
+	ctx := context.Background()
+	var opts []func(*config.LoadOptions) error
+	if *region != "" {
+		opts = append(opts, config.WithRegion(*region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	matrix, err := ec2handler.GetAvailabilityMatrix(ctx, patterns, ec2.NewFromConfig(cfg))
+	if err != nil {
+		return err
+	}
+	out, err := ec2handler.FormatMatrix(matrix, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// runASG - print which overrides are offered in the zones of an Auto Scaling group (or of the given subnets), failing
+// if any zone has none
+func runASG(args []string) error {
+	fs := flag.NewFlagSet("asg", flag.ExitOnError)
+	name := fs.String("name", "", "Auto Scaling group name")
+	overridesFlag := fs.String("overrides", "", "comma separated override instance types, instead of -name")
+	subnetsFlag := fs.String("subnets", "", "comma separated subnet IDs, instead of -name")
+	format := fs.String("format", ec2handler.MatrixFormatTable, "output format: table, csv, json or markdown")
+	region := fs.String("region", "", "region of the group (defaults to the configured region)")
+	verbose := fs.Bool("v", false, "log the AWS calls")
+	_ = fs.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	overrides, subnets := splitList(*overridesFlag), splitList(*subnetsFlag)
+	if *name == "" && (len(overrides) == 0 || len(subnets) == 0) {
+		fs.Usage()
+		return fmt.Errorf("-name or both -overrides and -subnets are required")
+	}
+
+	ctx := context.Background()
+	var opts []func(*config.LoadOptions) error
+	if *region != "" {
+		opts = append(opts, config.WithRegion(*region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	svc := ec2.NewFromConfig(cfg)
+
+	if *name != "" {
+		overrides, subnets, err = ec2handler.GetAutoScalingGroupOverrides(ctx, *name, autoscaling.NewFromConfig(cfg), svc)
+		if err != nil {
+			return err
+		}
+	}
+	analysis, err := ec2handler.AnalyzeOverrides(ctx, overrides, subnets, svc)
+	if err != nil {
+		return err
+	}
+	analysis.AutoScalingGroupName = *name
+	analysis.Region = cfg.Region
+
+	out, err := ec2handler.FormatOverrideAnalysis(analysis, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	if len(analysis.ZonesWithoutOverrides) > 0 {
+		return fmt.Errorf("%d zone(s) have no override offered: %s", len(analysis.ZonesWithoutOverrides),
+			strings.Join(analysis.ZonesWithoutOverrides, ", "))
+	}
+	return nil
+}
+
+// splitList - split a comma separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
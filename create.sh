@@ -8,6 +8,12 @@ aws iam create-role --role-name InstanceTypAZCheck --assume-role-policy-document
 aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole
 aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/AmazonEC2FullAccess
 aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/AWSCloudFormationReadOnlyAccess
+aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/ServiceQuotasReadOnlyAccess
+aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/AmazonRDSReadOnlyAccess
+aws iam attach-role-policy --role-name InstanceTypAZCheck --policy-arn arn:aws:iam::aws:policy/AmazonElastiCacheReadOnlyAccess
+aws iam put-role-policy --role-name InstanceTypAZCheck --policy-name EKSDescribeCluster --policy-document file://./eks-policy.json
+aws iam put-role-policy --role-name InstanceTypAZCheck --policy-name OutpostsGetInstanceTypes --policy-document file://./outposts-policy.json
+aws iam put-role-policy --role-name InstanceTypAZCheck --policy-name AssumeCheckRoles --policy-document file://./assume-role-policy.json
 
 env GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o /tmp/main InstanceTypAZCheck.go
 zip -j /tmp/main.zip /tmp/main
@@ -0,0 +1,234 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// MockAutoScalingClient is a mock implementation of the AutoScalingClient interface.
+type MockAutoScalingClient struct {
+	mockDescribeAutoScalingGroups func(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+}
+
+func (m *MockAutoScalingClient) DescribeAutoScalingGroups(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return m.mockDescribeAutoScalingGroups(ctx, params, optFns...)
+}
+
+func TestGetAutoScalingGroupOverrides(t *testing.T) {
+	mockEC2Client := newRegionMock([]types.InstanceTypeInfo{instanceTypeInfo("t3.small", 2, 2048)}, nil)
+	groups := map[string]astypes.AutoScalingGroup{
+		"web": {
+			VPCZoneIdentifier: aws.String("subnet-a, subnet-b,subnet-e"),
+			MixedInstancesPolicy: &astypes.MixedInstancesPolicy{
+				LaunchTemplate: &astypes.LaunchTemplate{
+					LaunchTemplateSpecification: &astypes.LaunchTemplateSpecification{
+						LaunchTemplateId: aws.String("lt-web"),
+						Version:          aws.String("$Latest"),
+					},
+					Overrides: []astypes.LaunchTemplateOverrides{
+						{InstanceType: aws.String("c7g.large")},
+						{InstanceRequirements: &astypes.InstanceRequirements{
+							VCpuCount:             &astypes.VCpuCountRequest{Min: aws.Int32(2), Max: aws.Int32(2)},
+							MemoryMiB:             &astypes.MemoryMiBRequest{Min: aws.Int32(2048)},
+							CpuManufacturers:      []astypes.CpuManufacturer{astypes.CpuManufacturerAmazonWebServices},
+							ExcludedInstanceTypes: []string{"c6g.*"},
+							AcceleratorCount:      &astypes.AcceleratorCountRequest{Max: aws.Int32(0)},
+						}},
+						{InstanceType: aws.String("c7g.large"), WeightedCapacity: aws.String("2")},
+					},
+				},
+			},
+		},
+		"single": {VPCZoneIdentifier: aws.String("subnet-a")},
+	}
+	mockAutoScalingClient := &MockAutoScalingClient{
+		mockDescribeAutoScalingGroups: func(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+			output := &autoscaling.DescribeAutoScalingGroupsOutput{}
+			if group, ok := groups[params.AutoScalingGroupNames[0]]; ok {
+				output.AutoScalingGroups = []astypes.AutoScalingGroup{group}
+			}
+			return output, nil
+		},
+	}
+	mockEC2Client.mockDescribeLaunchTemplateVersions = func(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+		return &ec2.DescribeLaunchTemplateVersionsOutput{
+			LaunchTemplateVersions: []types.LaunchTemplateVersion{{
+				VersionNumber:      aws.Int64(3),
+				LaunchTemplateData: &types.ResponseLaunchTemplateData{ImageId: aws.String("ami-arm")},
+			}},
+		}, nil
+	}
+	mockEC2Client.mockDescribeImages = func(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+		return &ec2.DescribeImagesOutput{
+			Images: []types.Image{{ImageId: aws.String("ami-arm"), Architecture: types.ArchitectureValuesArm64}},
+		}, nil
+	}
+	mockEC2Client.mockGetInstanceTypesFromInstanceRequirements = func(ctx context.Context, params *ec2.GetInstanceTypesFromInstanceRequirementsInput, optFns ...func(*ec2.Options)) (*ec2.GetInstanceTypesFromInstanceRequirementsOutput, error) {
+		// Every requirement is passed on, limited to the architecture of the template's AMI
+		requirements := params.InstanceRequirements
+		if !reflect.DeepEqual(params.ArchitectureTypes, []types.ArchitectureType{types.ArchitectureTypeArm64}) ||
+			!reflect.DeepEqual(requirements.CpuManufacturers, []types.CpuManufacturer{types.CpuManufacturerAmazonWebServices}) ||
+			!reflect.DeepEqual(requirements.ExcludedInstanceTypes, []string{"c6g.*"}) ||
+			requirements.AcceleratorCount == nil || aws.ToInt32(requirements.AcceleratorCount.Max) != 0 ||
+			aws.ToInt32(requirements.VCpuCount.Max) != 2 || aws.ToInt32(requirements.MemoryMiB.Min) != 2048 {
+			t.Errorf("GetInstanceTypesFromInstanceRequirements() architectures %v, requirements %+v", params.ArchitectureTypes, requirements)
+		}
+		return &ec2.GetInstanceTypesFromInstanceRequirementsOutput{
+			InstanceTypes: []types.InstanceTypeInfoFromInstanceRequirements{{InstanceType: aws.String("t3.small")}},
+		}, nil
+	}
+
+	tests := []struct {
+		name          string
+		group         string
+		wantOverrides []string
+		wantSubnets   []string
+		wantErr       bool
+	}{
+		{
+			name:          "Mixed instances policy",
+			group:         "web",
+			wantOverrides: []string{"c7g.large", "t3.small"},
+			wantSubnets:   []string{"subnet-a", "subnet-b", "subnet-e"},
+		},
+		{
+			name:    "No mixed instances policy",
+			group:   "single",
+			wantErr: true,
+		},
+		{
+			name:    "Group not found",
+			group:   "missing",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOverrides, gotSubnets, err := GetAutoScalingGroupOverrides(context.Background(), tt.group, mockAutoScalingClient, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAutoScalingGroupOverrides() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotOverrides, tt.wantOverrides) {
+				t.Errorf("GetAutoScalingGroupOverrides() overrides = %v, want %v", gotOverrides, tt.wantOverrides)
+			}
+			if !reflect.DeepEqual(gotSubnets, tt.wantSubnets) {
+				t.Errorf("GetAutoScalingGroupOverrides() subnets = %v, want %v", gotSubnets, tt.wantSubnets)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOverrides(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{
+		instanceTypeInfo("c7g.large", 2, 4096),
+		instanceTypeInfo("c6g.large", 2, 4096),
+	}
+	offered := map[string][]string{
+		"c7g.large": {"us-east-1a", "us-east-1b"},
+		"c6g.large": {"us-east-1b"},
+	}
+	mockEC2Client := newRegionMock(catalogue, offered)
+
+	got, err := AnalyzeOverrides(context.Background(), []string{"c7g.large", "c6g.large"}, []string{"subnet-a", "subnet-b", "subnet-e"}, mockEC2Client)
+	if err != nil {
+		t.Fatalf("AnalyzeOverrides() error = %v", err)
+	}
+	want := OverrideAnalysis{
+		AvailabilityMatrix: AvailabilityMatrix{
+			Zones: []MatrixZone{
+				{ZoneName: "us-east-1a", ZoneId: "use1-az6"},
+				{ZoneName: "us-east-1b", ZoneId: "use1-az1"},
+				{ZoneName: "us-east-1e", ZoneId: "use1-az3"},
+			},
+			InstanceTypes: []MatrixRow{
+				{
+					InstanceType:   "c7g.large",
+					Offered:        map[string]bool{"us-east-1a": true, "us-east-1b": true, "us-east-1e": false},
+					MissingFromAZs: []string{"us-east-1e"},
+				},
+				{
+					InstanceType:   "c6g.large",
+					Offered:        map[string]bool{"us-east-1a": false, "us-east-1b": true, "us-east-1e": false},
+					MissingFromAZs: []string{"us-east-1a", "us-east-1e"},
+				},
+			},
+		},
+		SubnetsByAZ: map[string][]string{
+			"us-east-1a": {"subnet-a"},
+			"us-east-1b": {"subnet-b"},
+			"us-east-1e": {"subnet-e"},
+		},
+		ZonesWithoutOverrides: []string{"us-east-1e"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeOverrides() got = %+v, want %+v", got, want)
+	}
+
+	out, err := FormatOverrideAnalysis(got, MatrixFormatTable)
+	if err != nil {
+		t.Fatalf("FormatOverrideAnalysis() error = %v", err)
+	}
+	wantOut := "INSTANCE TYPE  us-east-1a  us-east-1b  us-east-1e\n" +
+		"               use1-az6    use1-az1    use1-az3\n" +
+		"c7g.large *    yes         yes         -\n" +
+		"c6g.large *    -           yes         -\n" +
+		"\n* 2 instance type(s) not offered in every zone\n" +
+		"\nWARNING: no override is offered in us-east-1e (subnet-e), the group never launches there\n"
+	if out != wantOut {
+		t.Errorf("FormatOverrideAnalysis() got = %q, want %q", out, wantOut)
+	}
+
+	if _, err = AnalyzeOverrides(context.Background(), []string{"c7g.large"}, []string{"subnet-a", "subnet-typo"}, mockEC2Client); err == nil {
+		t.Errorf("AnalyzeOverrides() with a missing subnet, want an error")
+	}
+}
+
+func TestGetLaunchTemplateOverrides(t *testing.T) {
+	candidates := []types.InstanceTypeInfo{
+		instanceTypeInfo("c7g.xlarge", 4, 8192),
+		instanceTypeInfo("c7g.large", 2, 4096),
+		instanceTypeInfo("c6g.large", 2, 4096),
+		instanceTypeInfo("c7g.2xlarge", 8, 16384),
+		instanceTypeInfo("c7g.large", 2, 4096),
+	}
+	byAZ := map[string][]string{
+		"us-east-1a": {"c7g.xlarge", "c7g.large", "c7g.2xlarge"},
+		"us-east-1b": {"c7g.xlarge", "c7g.large", "c6g.large", "c7g.2xlarge"},
+	}
+
+	tests := []struct {
+		name  string
+		zones []string
+		want  []LaunchTemplateOverride
+	}{
+		{
+			name:  "Offered in every zone, weighted by vCPUs",
+			zones: []string{"us-east-1a", "us-east-1b"},
+			want: []LaunchTemplateOverride{
+				{InstanceType: "c7g.xlarge", WeightedCapacity: "2"},
+				{InstanceType: "c7g.large", WeightedCapacity: "1"},
+				{InstanceType: "c7g.2xlarge", WeightedCapacity: "4"},
+			},
+		},
+		{
+			name:  "No zones",
+			zones: nil,
+			want:  []LaunchTemplateOverride{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetLaunchTemplateOverrides(candidates, byAZ, tt.zones); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLaunchTemplateOverrides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package ec2handler
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestEbsVolumeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  EbsVolume
+		wantErr bool
+	}{
+		{name: "gp3 with IOPS and throughput", volume: EbsVolume{VolumeType: "gp3", Iops: 6000, Throughput: 500}},
+		{name: "io2 at the Block Express limit", volume: EbsVolume{VolumeType: "io2", Iops: 256000}},
+		{name: "gp3 above the old 16000 IOPS and 1000 MiB/s", volume: EbsVolume{VolumeType: "gp3", Iops: 80000, Throughput: 2000}},
+		{name: "IOPS for gp2", volume: EbsVolume{VolumeType: "gp2", Iops: 3000}, wantErr: true},
+		{name: "Throughput for io2", volume: EbsVolume{VolumeType: "io2", Throughput: 500}, wantErr: true},
+		{name: "Unknown type", volume: EbsVolume{VolumeType: "gp4"}, wantErr: true},
+		{name: "IOPS without a type", volume: EbsVolume{Iops: 3000}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.volume.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckEbsCompatibility(t *testing.T) {
+	ebsInfo := func(nvme types.EbsNvmeSupport, maximumIops int32, maximumThroughput float64) *types.EbsInfo {
+		return &types.EbsInfo{
+			NvmeSupport: nvme,
+			EbsOptimizedInfo: &types.EbsOptimizedInfo{
+				MaximumIops:             aws.Int32(maximumIops),
+				MaximumThroughputInMBps: aws.Float64(maximumThroughput),
+			},
+		}
+	}
+	r7i := types.InstanceTypeInfo{
+		InstanceType: "r7i.4xlarge",
+		Hypervisor:   types.InstanceTypeHypervisorNitro,
+		EbsInfo:      ebsInfo(types.EbsNvmeSupportRequired, 40000, 1250),
+	}
+	r5metal := types.InstanceTypeInfo{
+		InstanceType: "r5.metal",
+		BareMetal:    aws.Bool(true),
+		EbsInfo:      ebsInfo(types.EbsNvmeSupportRequired, 80000, 2375),
+	}
+	m4 := types.InstanceTypeInfo{
+		InstanceType: "m4.xlarge",
+		Hypervisor:   types.InstanceTypeHypervisorXen,
+		EbsInfo:      ebsInfo(types.EbsNvmeSupportUnsupported, 6000, 93.75),
+	}
+	t2 := types.InstanceTypeInfo{
+		InstanceType: "t2.micro",
+		Hypervisor:   types.InstanceTypeHypervisorXen,
+		EbsInfo:      &types.EbsInfo{NvmeSupport: types.EbsNvmeSupportUnsupported},
+	}
+
+	tests := []struct {
+		name    string
+		volume  EbsVolume
+		info    types.InstanceTypeInfo
+		wantErr bool
+	}{
+		{name: "io2 on Nitro", volume: EbsVolume{VolumeType: "io2", Iops: 32000}, info: r7i},
+		{name: "io2 on bare metal", volume: EbsVolume{VolumeType: "io2", Iops: 64000}, info: r5metal},
+		{name: "io2 on Xen", volume: EbsVolume{VolumeType: "io2", Iops: 3000}, info: m4, wantErr: true},
+		{name: "io1 on Xen", volume: EbsVolume{VolumeType: "io1", Iops: 3000}, info: m4},
+		{name: "More IOPS than the instance can drive", volume: EbsVolume{VolumeType: "io2", Iops: 64000}, info: r7i, wantErr: true},
+		{name: "More throughput than the instance can drive", volume: EbsVolume{VolumeType: "gp3", Throughput: 125}, info: m4, wantErr: true},
+		{name: "gp3 without provisioned performance", volume: EbsVolume{VolumeType: "gp3"}, info: t2},
+		{name: "Provisioned IOPS on an instance that isn't EBS optimized", volume: EbsVolume{VolumeType: "gp3", Iops: 4000}, info: t2, wantErr: true},
+		{name: "No EBS info", volume: EbsVolume{VolumeType: "gp3"}, info: types.InstanceTypeInfo{InstanceType: "x.large"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckEbsCompatibility(tt.volume, tt.info); (err != nil) != tt.wantErr {
+				t.Errorf("CheckEbsCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package ec2handler
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestCheckImageCompatibility(t *testing.T) {
+	graviton := types.InstanceTypeInfo{
+		InstanceType:                 types.InstanceTypeT4gSmall,
+		ProcessorInfo:                &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureTypeArm64}},
+		SupportedBootModes:           []types.BootModeType{types.BootModeTypeUefi},
+		SupportedVirtualizationTypes: []types.VirtualizationType{types.VirtualizationTypeHvm},
+		SupportedRootDeviceTypes:     []types.RootDeviceType{types.RootDeviceTypeEbs},
+		NetworkInfo:                  &types.NetworkInfo{EnaSupport: types.EnaSupportRequired},
+		EbsInfo:                      &types.EbsInfo{NvmeSupport: types.EbsNvmeSupportRequired},
+	}
+	xen := types.InstanceTypeInfo{
+		InstanceType:                 types.InstanceTypeT2Micro,
+		ProcessorInfo:                &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureTypeI386, types.ArchitectureTypeX8664}},
+		SupportedBootModes:           []types.BootModeType{types.BootModeTypeLegacyBios},
+		SupportedVirtualizationTypes: []types.VirtualizationType{types.VirtualizationTypeHvm},
+		NetworkInfo:                  &types.NetworkInfo{EnaSupport: types.EnaSupportUnsupported},
+		EbsInfo:                      &types.EbsInfo{NvmeSupport: types.EbsNvmeSupportUnsupported},
+	}
+	armImage := types.Image{
+		ImageId:            aws.String("ami-arm"),
+		Architecture:       types.ArchitectureValuesArm64,
+		VirtualizationType: types.VirtualizationTypeHvm,
+		RootDeviceType:     types.DeviceTypeEbs,
+		EnaSupport:         aws.Bool(true),
+	}
+
+	tests := []struct {
+		name    string
+		image   types.Image
+		info    types.InstanceTypeInfo
+		wantErr string
+	}{
+		{
+			name:  "Graviton image on Graviton",
+			image: armImage,
+			info:  graviton,
+		},
+		{
+			name: "x86 image on Graviton",
+			image: types.Image{
+				ImageId:            aws.String("ami-x86"),
+				Architecture:       types.ArchitectureValuesX8664,
+				VirtualizationType: types.VirtualizationTypeHvm,
+				EnaSupport:         aws.Bool(true),
+			},
+			info:    graviton,
+			wantErr: "image ami-x86 is x86_64 but t4g.small supports [arm64]",
+		},
+		{
+			name: "UEFI only image on legacy BIOS type",
+			image: types.Image{
+				ImageId:            aws.String("ami-uefi"),
+				Architecture:       types.ArchitectureValuesX8664,
+				BootMode:           types.BootModeValuesUefi,
+				VirtualizationType: types.VirtualizationTypeHvm,
+			},
+			info:    xen,
+			wantErr: "image ami-uefi boots with uefi but t2.micro supports [legacy-bios]",
+		},
+		{
+			name: "UEFI preferred image on legacy BIOS type",
+			image: types.Image{
+				ImageId:            aws.String("ami-uefi-preferred"),
+				Architecture:       types.ArchitectureValuesX8664,
+				BootMode:           types.BootModeValuesUefiPreferred,
+				VirtualizationType: types.VirtualizationTypeHvm,
+			},
+			info: xen,
+		},
+		{
+			name: "Paravirtual image",
+			image: types.Image{
+				ImageId:            aws.String("ami-pv"),
+				Architecture:       types.ArchitectureValuesX8664,
+				VirtualizationType: types.VirtualizationTypeParavirtual,
+			},
+			info:    xen,
+			wantErr: "image ami-pv uses paravirtual virtualization but t2.micro supports [hvm]",
+		},
+		{
+			name: "Image without ENA on a Nitro type",
+			image: types.Image{
+				ImageId:            aws.String("ami-noena"),
+				Architecture:       types.ArchitectureValuesArm64,
+				VirtualizationType: types.VirtualizationTypeHvm,
+			},
+			info:    graviton,
+			wantErr: "t4g.small requires ENA but image ami-noena does not have ENA support enabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImageCompatibility(tt.image, tt.info)
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("CheckImageCompatibility() error = %v, want %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ec2handler
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestCheckPlacementCompatibility(t *testing.T) {
+	hpc := types.InstanceTypeInfo{
+		InstanceType: "hpc7g.16xlarge",
+		PlacementGroupInfo: &types.PlacementGroupInfo{SupportedStrategies: []types.PlacementGroupStrategy{
+			types.PlacementGroupStrategyCluster, types.PlacementGroupStrategyPartition, types.PlacementGroupStrategySpread,
+		}},
+		NetworkInfo: &types.NetworkInfo{EfaSupported: aws.Bool(true)},
+	}
+	burstable := types.InstanceTypeInfo{
+		InstanceType: "t3.small",
+		PlacementGroupInfo: &types.PlacementGroupInfo{SupportedStrategies: []types.PlacementGroupStrategy{
+			types.PlacementGroupStrategyPartition, types.PlacementGroupStrategySpread,
+		}},
+		NetworkInfo: &types.NetworkInfo{EfaSupported: aws.Bool(false)},
+	}
+
+	tests := []struct {
+		name       string
+		strategy   string
+		requireEFA bool
+		info       types.InstanceTypeInfo
+		wantErr    bool
+	}{
+		{name: "Cluster with EFA", strategy: "cluster", requireEFA: true, info: hpc},
+		{name: "Spread", strategy: "spread", info: burstable},
+		{name: "Cluster not supported", strategy: "cluster", info: burstable, wantErr: true},
+		{name: "EFA not supported", requireEFA: true, info: burstable, wantErr: true},
+		{name: "No placement group info", strategy: "partition", info: types.InstanceTypeInfo{InstanceType: "x.large"}, wantErr: true},
+		{name: "Nothing required", info: types.InstanceTypeInfo{InstanceType: "x.large"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPlacementCompatibility(tt.strategy, tt.requireEFA, tt.info); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPlacementCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := validatePlacementStrategy("clustered"); err == nil {
+		t.Errorf("validatePlacementStrategy() accepted an unknown strategy")
+	}
+}
@@ -0,0 +1,88 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// MockSTSClient is a mock implementation of the STSClient interface
+type MockSTSClient struct {
+	mockGetCallerIdentity func(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+func (m *MockSTSClient) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return m.mockGetCallerIdentity(ctx, params, optFns...)
+}
+
+func TestSharedSubnets(t *testing.T) {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-a"), OwnerId: aws.String(testAccountId)},
+		{SubnetId: aws.String("subnet-s"), OwnerId: aws.String(testOwnerId)},
+		{SubnetId: aws.String("subnet-n")},
+	}
+	got := SharedSubnets(subnets, testAccountId)
+	if len(got) != 1 || aws.ToString(got[0].SubnetId) != "subnet-s" {
+		t.Errorf("SharedSubnets() = %v, want [subnet-s]", got)
+	}
+}
+
+func TestCheckSharedSubnetLaunchPermission(t *testing.T) {
+	mockEC2Client := newRegionMock(nil, nil)
+	create := mockEC2Client.mockCreateNetworkInterface
+	mockEC2Client.mockCreateNetworkInterface = func(ctx context.Context, params *ec2.CreateNetworkInterfaceInput, optFns ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+		if !aws.ToBool(params.DryRun) {
+			t.Errorf("CreateNetworkInterface() without DryRun")
+		}
+		if !reflect.DeepEqual(params.Groups, []string{"sg-participant"}) {
+			t.Errorf("CreateNetworkInterface() Groups = %v", params.Groups)
+		}
+		return create(ctx, params, optFns...)
+	}
+
+	passed, failures, err := CheckSharedSubnetLaunchPermission(context.Background(), []string{"subnet-s", "subnet-x"}, []string{"sg-participant"}, mockEC2Client)
+	if err != nil {
+		t.Fatalf("CheckSharedSubnetLaunchPermission() error = %v", err)
+	}
+	if !reflect.DeepEqual(passed, []string{"subnet-s"}) {
+		t.Errorf("CheckSharedSubnetLaunchPermission() passed = %v, want [subnet-s]", passed)
+	}
+	if _, ok := failures["subnet-x"]; !ok || len(failures) != 1 {
+		t.Errorf("CheckSharedSubnetLaunchPermission() failures = %v, want subnet-x", failures)
+	}
+
+	// Not being allowed to create network interfaces at all isn't about the subnet
+	mockEC2Client.mockCreateNetworkInterface = func(ctx context.Context, params *ec2.CreateNetworkInterfaceInput, optFns ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+		return nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized to perform this operation."}
+	}
+	if _, _, err = CheckSharedSubnetLaunchPermission(context.Background(), []string{"subnet-s"}, []string{"sg-participant"}, mockEC2Client); err == nil {
+		t.Errorf("CheckSharedSubnetLaunchPermission() error = nil, want the UnauthorizedOperation error")
+	}
+}
+
+func TestGetInstanceTypeOfferingsByZoneId(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{instanceTypeInfo("t3.small", 2, 2048)}
+	offered := map[string][]string{"t3.small": {"us-east-1a", "us-east-1e"}}
+	zoneIds := map[string]string{"us-east-1a": "use1-az6", "us-east-1b": "use1-az1", "us-east-1e": "use1-az3"}
+
+	got, err := GetInstanceTypeOfferingsByZoneId(context.Background(), []string{"t3.small"}, zoneIds, newRegionMock(catalogue, offered))
+	if err != nil {
+		t.Fatalf("GetInstanceTypeOfferingsByZoneId() error = %v", err)
+	}
+	zones := make(map[string]bool)
+	for _, offering := range got {
+		if offering.LocationType != types.LocationTypeAvailabilityZone {
+			t.Errorf("GetInstanceTypeOfferingsByZoneId() LocationType = %v", offering.LocationType)
+		}
+		zones[aws.ToString(offering.Location)] = true
+	}
+	if want := map[string]bool{"us-east-1a": true, "us-east-1e": true}; !reflect.DeepEqual(zones, want) {
+		t.Errorf("GetInstanceTypeOfferingsByZoneId() zones = %v, want %v", zones, want)
+	}
+}
@@ -0,0 +1,162 @@
+package ec2handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	sqtypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+)
+
+// MockServiceQuotasClient is a mock implementation of the ServiceQuotasClient interface.
+type MockServiceQuotasClient struct {
+	mockGetServiceQuota           func(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error)
+	mockGetAWSDefaultServiceQuota func(ctx context.Context, params *servicequotas.GetAWSDefaultServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error)
+}
+
+func (m *MockServiceQuotasClient) GetServiceQuota(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error) {
+	return m.mockGetServiceQuota(ctx, params, optFns...)
+}
+
+func (m *MockServiceQuotasClient) GetAWSDefaultServiceQuota(ctx context.Context, params *servicequotas.GetAWSDefaultServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	return m.mockGetAWSDefaultServiceQuota(ctx, params, optFns...)
+}
+
+func TestGetVCpuQuotaCode(t *testing.T) {
+	tests := map[string]string{
+		"m7i.large":           "L-1216C47A",
+		"t4g.small":           "L-1216C47A",
+		"g5.xlarge":           "L-DB2E81BA",
+		"vt1.3xlarge":         "L-DB2E81BA",
+		"p5.48xlarge":         "L-417A185B",
+		"x2idn.16xlarge":      "L-7295265B",
+		"f1.2xlarge":          "L-74FC7D96",
+		"dl1.24xlarge":        "L-6E869C2A",
+		"inf2.xlarge":         "L-1945791B",
+		"trn1.2xlarge":        "L-2C3B7624",
+		"u-6tb1.metal":        "L-43DA4232",
+		"u7i-12tb.224xlarge":  "L-43DA4232",
+		"u7in-16tb.224xlarge": "L-43DA4232",
+		"im4gn.large":         "L-1216C47A",
+		"gr6.4xlarge":         "L-DB2E81BA",
+		"hpc7g.16xlarge":      "L-F7808C92",
+		"zz9.large":           "",
+		"mac2.metal":          "",
+	}
+	for instanceType, want := range tests {
+		if got := GetVCpuQuotaCode(instanceType); got != want {
+			t.Errorf("GetVCpuQuotaCode(%v) = %v, want %v", instanceType, got, want)
+		}
+	}
+}
+
+func TestCheckVCpuQuota(t *testing.T) {
+	instance := func(instanceType string, vcpus int32, lifecycle types.InstanceLifecycleType) types.Instance {
+		return types.Instance{
+			InstanceType:      types.InstanceType(instanceType),
+			InstanceLifecycle: lifecycle,
+			CpuOptions:        &types.CpuOptions{CoreCount: aws.Int32(vcpus / 2), ThreadsPerCore: aws.Int32(2)},
+		}
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeInstances: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{
+					{Instances: []types.Instance{
+						instance("m7i.2xlarge", 8, ""),
+						instance("c7i.xlarge", 4, ""),
+						instance("m7i.4xlarge", 16, types.InstanceLifecycleTypeSpot),
+						instance("g5.xlarge", 4, ""),
+					}},
+				},
+			}, nil
+		},
+	}
+	quotaValue := func(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error) {
+		return &servicequotas.GetServiceQuotaOutput{
+			Quota: &sqtypes.ServiceQuota{QuotaName: aws.String("Running On-Demand Standard instances"), Value: aws.Float64(32)},
+		}, nil
+	}
+	notApplied := func(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error) {
+		return nil, &sqtypes.NoSuchResourceException{Message: aws.String("not applied")}
+	}
+
+	tests := []struct {
+		name         string
+		instanceType string
+		vcpus        int32
+		desiredCount int
+		warnOnly     bool
+		getQuota     func(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error)
+		want         VCpuQuota
+		wantErr      bool
+	}{
+		{
+			name:         "Fits",
+			instanceType: "m7i.large",
+			vcpus:        2,
+			desiredCount: 10,
+			getQuota:     quotaValue,
+			want:         VCpuQuota{QuotaCode: "L-1216C47A", QuotaName: "Running On-Demand Standard instances", Limit: 32, Usage: 12, Required: 20},
+		},
+		{
+			name:         "Exceeded",
+			instanceType: "m7i.large",
+			vcpus:        2,
+			desiredCount: 11,
+			getQuota:     quotaValue,
+			wantErr:      true,
+		},
+		{
+			name:         "Exceeded with a warning",
+			instanceType: "m7i.large",
+			vcpus:        2,
+			desiredCount: 11,
+			warnOnly:     true,
+			getQuota:     quotaValue,
+			want: VCpuQuota{QuotaCode: "L-1216C47A", QuotaName: "Running On-Demand Standard instances", Limit: 32, Usage: 12, Required: 22, Exceeded: true,
+				Warning: `11 x m7i.large needs 22 vCPUs but 12 of the 32 vCPU "Running On-Demand Standard instances" quota (L-1216C47A) are in use`},
+		},
+		{
+			name:         "Default quota when not applied",
+			instanceType: "g5.2xlarge",
+			vcpus:        8,
+			desiredCount: 1,
+			getQuota:     notApplied,
+			want:         VCpuQuota{QuotaCode: "L-DB2E81BA", QuotaName: "Running On-Demand G and VT instances", Limit: 64, Usage: 4, Required: 8},
+		},
+		{
+			name:         "No quota for mac instances",
+			instanceType: "mac2.metal",
+			vcpus:        12,
+			desiredCount: 1,
+			want:         VCpuQuota{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotas := &MockServiceQuotasClient{
+				mockGetServiceQuota: tt.getQuota,
+				mockGetAWSDefaultServiceQuota: func(ctx context.Context, params *servicequotas.GetAWSDefaultServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+					return &servicequotas.GetAWSDefaultServiceQuotaOutput{
+						Quota: &sqtypes.ServiceQuota{QuotaName: aws.String("Running On-Demand G and VT instances"), Value: aws.Float64(64)},
+					}, nil
+				},
+			}
+			got, err := CheckVCpuQuota(context.Background(), tt.instanceType, tt.vcpus, tt.desiredCount, tt.warnOnly, mockEC2Client, quotas)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckVCpuQuota() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CheckVCpuQuota() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
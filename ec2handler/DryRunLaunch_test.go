@@ -0,0 +1,105 @@
+package ec2handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+)
+
+func TestDryRunLaunch(t *testing.T) {
+	spec := LaunchSpec{
+		ImageId:            "ami-x86",
+		KeyName:            "ops",
+		SecurityGroupIds:   []string{"sg-1"},
+		IamInstanceProfile: "arn:aws:iam::111122223333:instance-profile/app",
+	}
+
+	tests := []struct {
+		name         string
+		runInstances func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+		wantPassed   []string
+		wantFailures map[string]string
+		wantErr      bool
+	}{
+		{
+			name: "Rejected in one subnet",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				if !aws.ToBool(params.DryRun) || aws.ToString(params.KeyName) != "ops" || params.IamInstanceProfile.Arn == nil {
+					t.Errorf("RunInstances() input = %+v", params)
+				}
+				if aws.ToString(params.SubnetId) == "subnet-b" {
+					return nil, &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: "We currently do not have sufficient t3.small capacity."}
+				}
+				return nil, &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}
+			},
+			wantPassed:   []string{"subnet-a", "subnet-e"},
+			wantFailures: map[string]string{"subnet-b": "InsufficientInstanceCapacity: We currently do not have sufficient t3.small capacity."},
+		},
+		{
+			name: "Invalid security group",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				if aws.ToString(params.SubnetId) != "subnet-a" {
+					return nil, &smithy.GenericAPIError{Code: "InvalidGroup.NotFound", Message: "The security group 'sg-1' does not exist in VPC 'vpc-2'"}
+				}
+				return nil, &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}
+			},
+			wantPassed: []string{"subnet-a"},
+			wantFailures: map[string]string{
+				"subnet-b": "InvalidGroup.NotFound: The security group 'sg-1' does not exist in VPC 'vpc-2'",
+				"subnet-e": "InvalidGroup.NotFound: The security group 'sg-1' does not exist in VPC 'vpc-2'",
+			},
+		},
+		{
+			name: "Throttled",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				return nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded."}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Not authorized",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				return nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized to perform this operation."}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Server error",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				return nil, &smithy.GenericAPIError{Code: "InternalError", Message: "An internal error has occurred.", Fault: smithy.FaultServer}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Not an API error",
+			runInstances: func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				return nil, errors.New("connection reset")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2Client := &MockEC2Client{mockRunInstances: tt.runInstances}
+			gotPassed, gotFailures, err := DryRunLaunch(context.Background(), "t3.small", []string{"subnet-a", "subnet-b", "subnet-e"}, spec, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DryRunLaunch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(gotPassed, tt.wantPassed) {
+				t.Errorf("DryRunLaunch() passed = %v, want %v", gotPassed, tt.wantPassed)
+			}
+			if !reflect.DeepEqual(gotFailures, tt.wantFailures) {
+				t.Errorf("DryRunLaunch() failures = %v, want %v", gotFailures, tt.wantFailures)
+			}
+		})
+	}
+}
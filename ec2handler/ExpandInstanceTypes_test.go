@@ -0,0 +1,89 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instanceTypeInfo - a minimal InstanceTypeInfo for the mocks, Graviton if the family ends in g
+func instanceTypeInfo(instanceType string, vcpus int32, memory int64) types.InstanceTypeInfo {
+	architecture := types.ArchitectureTypeX8664
+	if family, _, _ := strings.Cut(instanceType, "."); strings.HasSuffix(family, "g") {
+		architecture = types.ArchitectureTypeArm64
+	}
+	return types.InstanceTypeInfo{
+		InstanceType:  types.InstanceType(instanceType),
+		VCpuInfo:      &types.VCpuInfo{DefaultVCpus: aws.Int32(vcpus)},
+		MemoryInfo:    &types.MemoryInfo{SizeInMiB: aws.Int64(memory)},
+		ProcessorInfo: &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{architecture}},
+		PlacementGroupInfo: &types.PlacementGroupInfo{SupportedStrategies: []types.PlacementGroupStrategy{
+			types.PlacementGroupStrategyCluster, types.PlacementGroupStrategyPartition, types.PlacementGroupStrategySpread,
+		}},
+	}
+}
+
+func TestExpandInstanceTypes(t *testing.T) {
+	mockEC2Client := &MockEC2Client{}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		setup    func()
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "Patterns in order of preference, smallest first",
+			patterns: []string{"c7g.*large", "t4g.large"},
+			setup: func() {
+				mockEC2Client.mockDescribeInstanceTypes = func(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+					if params.NextToken == nil {
+						return &ec2.DescribeInstanceTypesOutput{
+							InstanceTypes: []types.InstanceTypeInfo{
+								instanceTypeInfo("t4g.large", 2, 8192),
+								instanceTypeInfo("c7g.2xlarge", 8, 16384),
+							},
+							NextToken: aws.String("page-2"),
+						}, nil
+					}
+					return &ec2.DescribeInstanceTypesOutput{
+						InstanceTypes: []types.InstanceTypeInfo{
+							instanceTypeInfo("c7g.xlarge", 4, 8192),
+							instanceTypeInfo("c7g.large", 2, 4096),
+						},
+					}, nil
+				}
+			},
+			want: []string{"c7g.large", "c7g.xlarge", "c7g.2xlarge", "t4g.large"},
+		},
+		{
+			name:     "No patterns",
+			patterns: []string{},
+			setup:    func() {},
+			want:     []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			infos, err := ExpandInstanceTypes(context.Background(), tt.patterns, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExpandInstanceTypes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got := make([]string, 0, len(infos))
+			for _, info := range infos {
+				got = append(got, string(info.InstanceType))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandInstanceTypes() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestFindEquivalentInstanceTypes(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{
+		instanceTypeInfo("t4g.small", 2, 2048),
+		instanceTypeInfo("a1.small", 2, 2048),
+		instanceTypeInfo("t3a.small", 2, 2048),
+		instanceTypeInfo("t3.small", 2, 2048),
+		instanceTypeInfo("t2.small", 1, 2048),
+		instanceTypeInfo("t4g.medium", 2, 4096),
+	}
+	offered := map[string][]string{
+		"t4g.small":  {"us-east-1a"},
+		"a1.small":   {"us-east-1a", "us-east-1b", "us-east-1e"},
+		"t3a.small":  {"us-east-1a", "us-east-1b"},
+		"t3.small":   {"us-east-1a", "us-east-1b", "us-east-1e"},
+		"t2.small":   {"us-east-1a", "us-east-1b", "us-east-1e"},
+		"t4g.medium": {"us-east-1a", "us-east-1b", "us-east-1e"},
+	}
+	zones := map[string]string{"us-east-1a": "use1-az6", "us-east-1b": "use1-az1", "us-east-1e": "use1-az3"}
+
+	// a1 is Graviton too
+	catalogue[1].ProcessorInfo = &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureTypeArm64}}
+	x86Image := types.Image{ImageId: aws.String("ami-x86"), Architecture: types.ArchitectureValuesX8664}
+
+	tests := []struct {
+		name         string
+		original     types.InstanceTypeInfo
+		check        func(types.InstanceTypeInfo) error
+		minimumZones int
+		want         []string
+	}{
+		{
+			name:         "Same class first, then the zones covered",
+			original:     catalogue[0],
+			minimumZones: 2,
+			want:         []string{"t3.small", "t3a.small", "a1.small"},
+		},
+		{
+			name:         "Only types offered in every zone",
+			original:     catalogue[0],
+			minimumZones: 3,
+			want:         []string{"t3.small", "a1.small"},
+		},
+		{
+			name:     "Only types the image can boot on",
+			original: catalogue[0],
+			check: func(info types.InstanceTypeInfo) error {
+				return CheckImageCompatibility(x86Image, info)
+			},
+			minimumZones: 2,
+			want:         []string{"t3.small", "t3a.small"},
+		},
+		{
+			name:         "Nothing to compare",
+			original:     types.InstanceTypeInfo{InstanceType: "t4g.small"},
+			minimumZones: 3,
+			want:         []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindEquivalentInstanceTypes(context.Background(), tt.original, tt.check, zones, tt.minimumZones, newRegionMock(catalogue, offered))
+			if err != nil {
+				t.Errorf("FindEquivalentInstanceTypes() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindEquivalentInstanceTypes() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
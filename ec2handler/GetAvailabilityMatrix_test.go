@@ -0,0 +1,181 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetAvailabilityMatrix(t *testing.T) {
+	mockEC2Client := &MockEC2Client{
+		mockDescribeAvailabilityZones: func(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
+			return &ec2.DescribeAvailabilityZonesOutput{
+				AvailabilityZones: []types.AvailabilityZone{
+					{RegionName: aws.String("us-east-1"), ZoneName: aws.String("us-east-1b"), ZoneId: aws.String("use1-az1")},
+					{RegionName: aws.String("us-east-1"), ZoneName: aws.String("us-east-1a"), ZoneId: aws.String("use1-az6")},
+					{RegionName: aws.String("us-east-1"), ZoneName: aws.String("us-east-1e"), ZoneId: aws.String("use1-az3")},
+				},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		setup     func()
+		wantZones []MatrixZone
+		wantRows  []MatrixRow
+		wantErr   bool
+	}{
+		{
+			name:     "Paged offerings with a type missing from one zone",
+			patterns: []string{"t4g.*"},
+			setup: func() {
+				mockEC2Client.mockDescribeInstanceTypeOfferings = func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+					if params.NextToken == nil {
+						return &ec2.DescribeInstanceTypeOfferingsOutput{
+							InstanceTypeOfferings: []types.InstanceTypeOffering{
+								{InstanceType: types.InstanceTypeT4gSmall, Location: aws.String("us-east-1a")},
+								{InstanceType: types.InstanceTypeT4gSmall, Location: aws.String("us-east-1b")},
+								{InstanceType: types.InstanceTypeT4gMicro, Location: aws.String("us-east-1a")},
+							},
+							NextToken: aws.String("page-2"),
+						}, nil
+					}
+					return &ec2.DescribeInstanceTypeOfferingsOutput{
+						InstanceTypeOfferings: []types.InstanceTypeOffering{
+							{InstanceType: types.InstanceTypeT4gMicro, Location: aws.String("us-east-1b")},
+							{InstanceType: types.InstanceTypeT4gMicro, Location: aws.String("us-east-1e")},
+						},
+					}, nil
+				}
+			},
+			wantZones: []MatrixZone{
+				{ZoneName: "us-east-1a", ZoneId: "use1-az6"},
+				{ZoneName: "us-east-1b", ZoneId: "use1-az1"},
+				{ZoneName: "us-east-1e", ZoneId: "use1-az3"},
+			},
+			wantRows: []MatrixRow{
+				{
+					InstanceType:   "t4g.micro",
+					Offered:        map[string]bool{"us-east-1a": true, "us-east-1b": true, "us-east-1e": true},
+					MissingFromAZs: []string{},
+				},
+				{
+					InstanceType:   "t4g.small",
+					Offered:        map[string]bool{"us-east-1a": true, "us-east-1b": true, "us-east-1e": false},
+					MissingFromAZs: []string{"us-east-1e"},
+				},
+			},
+		},
+		{
+			name:     "Pattern matching no offered type",
+			patterns: []string{"t4g.*", "m7x.*"},
+			setup: func() {
+				mockEC2Client.mockDescribeInstanceTypeOfferings = func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+					return &ec2.DescribeInstanceTypeOfferingsOutput{
+						InstanceTypeOfferings: []types.InstanceTypeOffering{
+							{InstanceType: types.InstanceTypeT4gSmall, Location: aws.String("us-east-1a")},
+						},
+					}, nil
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name:     "No patterns",
+			patterns: []string{},
+			setup:    func() {},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			got, err := GetAvailabilityMatrix(context.Background(), tt.patterns, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAvailabilityMatrix() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Region != "us-east-1" {
+				t.Errorf("GetAvailabilityMatrix() Region = %v, want us-east-1", got.Region)
+			}
+			if !reflect.DeepEqual(got.Zones, tt.wantZones) {
+				t.Errorf("GetAvailabilityMatrix() Zones = %v, want %v", got.Zones, tt.wantZones)
+			}
+			if !reflect.DeepEqual(got.InstanceTypes, tt.wantRows) {
+				t.Errorf("GetAvailabilityMatrix() InstanceTypes = %v, want %v", got.InstanceTypes, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestFormatMatrix(t *testing.T) {
+	matrix := AvailabilityMatrix{
+		Region: "us-east-1",
+		Zones: []MatrixZone{
+			{ZoneName: "us-east-1a", ZoneId: "use1-az6"},
+			{ZoneName: "us-east-1e", ZoneId: "use1-az3"},
+		},
+		InstanceTypes: []MatrixRow{
+			{
+				InstanceType:   "t4g.small",
+				Offered:        map[string]bool{"us-east-1a": true, "us-east-1e": false},
+				MissingFromAZs: []string{"us-east-1e"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "CSV",
+			format: MatrixFormatCSV,
+			want: "InstanceType,us-east-1a (use1-az6),us-east-1e (use1-az3),MissingFromAZs\n" +
+				"t4g.small,true,false,us-east-1e\n",
+		},
+		{
+			name:   "Markdown",
+			format: MatrixFormatMarkdown,
+			want: "| Instance Type | us-east-1a (use1-az6) | us-east-1e (use1-az3) |\n" +
+				"|---|:---:|:---:|\n" +
+				"| **t4g.small** | ✅ | ❌ |\n",
+		},
+		{
+			name:   "Table",
+			format: MatrixFormatTable,
+			want: "INSTANCE TYPE  us-east-1a  us-east-1e\n" +
+				"               use1-az6    use1-az3\n" +
+				"t4g.small *    yes         -\n" +
+				"\n* 1 instance type(s) not offered in every zone\n",
+		},
+		{
+			name:    "Unknown format",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatMatrix(matrix, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FormatMatrix() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FormatMatrix() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetVpcTenancy(t *testing.T) {
+	mockEC2Client := &MockEC2Client{
+		mockDescribeVpcs: func(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+			output := &ec2.DescribeVpcsOutput{}
+			for _, vpcId := range params.VpcIds {
+				tenancy := types.TenancyDefault
+				if vpcId == "vpc-dedicated" {
+					tenancy = types.TenancyDedicated
+				}
+				output.Vpcs = append(output.Vpcs, types.Vpc{VpcId: aws.String(vpcId), InstanceTenancy: tenancy})
+			}
+			return output, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		subnets []types.Subnet
+		want    string
+	}{
+		{
+			name:    "Default VPC",
+			subnets: []types.Subnet{{VpcId: aws.String("vpc-default")}, {VpcId: aws.String("vpc-default")}},
+			want:    TenancyDefault,
+		},
+		{
+			name:    "Dedicated VPC",
+			subnets: []types.Subnet{{VpcId: aws.String("vpc-dedicated")}},
+			want:    TenancyDedicated,
+		},
+		{
+			name:    "No VPC",
+			subnets: []types.Subnet{{SubnetId: aws.String("subnet-a")}},
+			want:    TenancyDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetVpcTenancy(context.Background(), tt.subnets, mockEC2Client)
+			if err != nil {
+				t.Fatalf("GetVpcTenancy() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetVpcTenancy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTenancyCompatibility(t *testing.T) {
+	mac := types.InstanceTypeInfo{InstanceType: "mac2.metal", DedicatedHostsSupported: aws.Bool(true)}
+	burstable := types.InstanceTypeInfo{InstanceType: "t4g.small", DedicatedHostsSupported: aws.Bool(false)}
+
+	tests := []struct {
+		name    string
+		tenancy string
+		info    types.InstanceTypeInfo
+		wantErr bool
+	}{
+		{name: "Host", tenancy: TenancyHost, info: mac},
+		{name: "Dedicated", tenancy: TenancyDedicated, info: mac},
+		{name: "Default", tenancy: TenancyDefault, info: burstable},
+		{name: "Dedicated without Dedicated Host support", tenancy: TenancyDedicated, info: burstable},
+		{name: "Host not supported", tenancy: TenancyHost, info: burstable, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckTenancyCompatibility(tt.tenancy, tt.info); (err != nil) != tt.wantErr {
+				t.Errorf("CheckTenancyCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAvailableHosts(t *testing.T) {
+	zoneIds := map[string]string{"us-east-1a": "use1-az6", "us-east-1b": "use1-az1"}
+	host := func(hostId string, zone string, capacity map[string]int32) types.Host {
+		h := types.Host{HostId: aws.String(hostId), AvailabilityZone: aws.String(zone), AvailabilityZoneId: aws.String(zoneIds[zone]), AvailableCapacity: &types.AvailableCapacity{}}
+		for instanceType, count := range capacity {
+			h.AvailableCapacity.AvailableInstanceCapacity = append(h.AvailableCapacity.AvailableInstanceCapacity,
+				types.InstanceCapacity{InstanceType: aws.String(instanceType), AvailableCapacity: aws.Int32(count)})
+		}
+		return h
+	}
+	pages := [][]types.Host{
+		{
+			host("h-full", "us-east-1a", map[string]int32{"mac2.metal": 0}),
+			host("h-a1", "us-east-1a", map[string]int32{"mac2.metal": 1}),
+		},
+		{
+			host("h-a2", "us-east-1a", map[string]int32{"mac2.metal": 2}),
+			host("h-m5", "us-east-1b", map[string]int32{"m5.large": 48}),
+		},
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeHosts: func(ctx context.Context, params *ec2.DescribeHostsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeHostsOutput, error) {
+			if state := filterValues(params.Filter, "state"); !reflect.DeepEqual(state, []string{"available"}) {
+				t.Errorf("DescribeHosts() state filter = %v", state)
+			}
+			if params.NextToken == nil {
+				return &ec2.DescribeHostsOutput{Hosts: pages[0], NextToken: aws.String("page-2")}, nil
+			}
+			return &ec2.DescribeHostsOutput{Hosts: pages[1]}, nil
+		},
+	}
+
+	got, err := GetAvailableHosts(context.Background(), "mac2.metal", zoneIds, mockEC2Client)
+	if err != nil {
+		t.Fatalf("GetAvailableHosts() error = %v", err)
+	}
+	want := map[string][]string{"us-east-1a": {"h-a2", "h-a1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAvailableHosts() = %v, want %v", got, want)
+	}
+}
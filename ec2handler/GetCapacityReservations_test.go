@@ -0,0 +1,110 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetCapacityReservations(t *testing.T) {
+	zoneIds := map[string]string{"us-east-1a": "use1-az6", "us-east-1b": "use1-az1"}
+	reservation := func(id, zone string, available int32, owner string) types.CapacityReservation {
+		return types.CapacityReservation{
+			CapacityReservationId:  aws.String(id),
+			AvailabilityZone:       aws.String(zone),
+			AvailabilityZoneId:     aws.String(zoneIds[zone]),
+			AvailableInstanceCount: aws.Int32(available),
+			OwnerId:                aws.String(owner),
+			State:                  types.CapacityReservationStateActive,
+		}
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeCapacityReservations: func(ctx context.Context, params *ec2.DescribeCapacityReservationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error) {
+			if got := filterValues(params.Filters, "state"); !reflect.DeepEqual(got, []string{"active"}) {
+				t.Errorf("DescribeCapacityReservations() state filter = %v", got)
+			}
+			if params.NextToken == nil {
+				return &ec2.DescribeCapacityReservationsOutput{
+					CapacityReservations: []types.CapacityReservation{
+						reservation("cr-full", "us-east-1a", 0, "111122223333"),
+						reservation("cr-a", "us-east-1a", 1, "111122223333"),
+					},
+					NextToken: aws.String("page-2"),
+				}, nil
+			}
+			return &ec2.DescribeCapacityReservationsOutput{
+				CapacityReservations: []types.CapacityReservation{
+					reservation("cr-shared", "us-east-1a", 5, "444455556666"),
+					reservation("cr-b", "us-east-1b", 2, "111122223333"),
+					// us-east-1a in the owner's mapping, but use1-az3 isn't one of the zones
+					{
+						CapacityReservationId:  aws.String("cr-other-zone"),
+						AvailabilityZone:       aws.String("us-east-1a"),
+						AvailabilityZoneId:     aws.String("use1-az3"),
+						AvailableInstanceCount: aws.Int32(9),
+						OwnerId:                aws.String("444455556666"),
+						State:                  types.CapacityReservationStateActive,
+					},
+				},
+			}, nil
+		},
+		mockGetGroupsForCapacityReservation: func(ctx context.Context, params *ec2.GetGroupsForCapacityReservationInput, optFns ...func(*ec2.Options)) (*ec2.GetGroupsForCapacityReservationOutput, error) {
+			groups := map[string][]types.CapacityReservationGroup{
+				"cr-a": {{GroupArn: aws.String("arn:aws:resource-groups:us-east-1:111122223333:group/critical")}},
+				"cr-b": {{GroupArn: aws.String("arn:aws:resource-groups:us-east-1:111122223333:group/other")}},
+			}
+			return &ec2.GetGroupsForCapacityReservationOutput{
+				CapacityReservationGroups: groups[aws.ToString(params.CapacityReservationId)],
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		groupArn string
+		want     map[string]CapacityReservation
+	}{
+		{
+			name: "Most free capacity in each zone, shared included",
+			want: map[string]CapacityReservation{
+				"us-east-1a": {CapacityReservationId: "cr-shared", AvailableInstanceCount: 5, OwnerId: "444455556666"},
+				"us-east-1b": {CapacityReservationId: "cr-b", AvailableInstanceCount: 2, OwnerId: "111122223333"},
+			},
+		},
+		{
+			name:     "Only reservations in the group",
+			groupArn: "arn:aws:resource-groups:us-east-1:111122223333:group/critical",
+			want: map[string]CapacityReservation{
+				"us-east-1a": {CapacityReservationId: "cr-a", AvailableInstanceCount: 1, OwnerId: "111122223333"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetCapacityReservations(context.Background(), "m7i.large", zoneIds, tt.groupArn, mockEC2Client)
+			if err != nil {
+				t.Errorf("GetCapacityReservations() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCapacityReservations() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferCapacityReservations(t *testing.T) {
+	zones := []string{"us-east-1a", "us-east-1b", "us-east-1c", "us-east-1d"}
+	PreferCapacityReservations(zones, map[string]CapacityReservation{
+		"us-east-1c": {CapacityReservationId: "cr-c", AvailableInstanceCount: 1},
+		"us-east-1b": {CapacityReservationId: "cr-b", AvailableInstanceCount: 3},
+	})
+	want := []string{"us-east-1b", "us-east-1c", "us-east-1a", "us-east-1d"}
+	if !reflect.DeepEqual(zones, want) {
+		t.Errorf("PreferCapacityReservations() got = %v, want %v", zones, want)
+	}
+}
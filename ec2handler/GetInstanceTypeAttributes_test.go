@@ -0,0 +1,77 @@
+package ec2handler
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetInstanceTypeAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		info types.InstanceTypeInfo
+		want InstanceTypeAttributes
+	}{
+		{
+			name: "Graviton burstable",
+			info: types.InstanceTypeInfo{
+				InstanceType:  types.InstanceTypeT4gSmall,
+				Hypervisor:    types.InstanceTypeHypervisorNitro,
+				ProcessorInfo: &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureTypeArm64}},
+				VCpuInfo:      &types.VCpuInfo{DefaultVCpus: aws.Int32(2)},
+				MemoryInfo:    &types.MemoryInfo{SizeInMiB: aws.Int64(2048)},
+				EbsInfo:       &types.EbsInfo{EbsOptimizedSupport: types.EbsOptimizedSupportDefault},
+				NetworkInfo: &types.NetworkInfo{
+					MaximumNetworkInterfaces:  aws.Int32(3),
+					Ipv4AddressesPerInterface: aws.Int32(4),
+				},
+			},
+			want: InstanceTypeAttributes{
+				Architecture:   "arm64",
+				VCpus:          2,
+				MemoryMiB:      2048,
+				MaxENIs:        3,
+				IPv4PerENI:     4,
+				EbsOptimized:   true,
+				HypervisorType: "nitro",
+			},
+		},
+		{
+			name: "Older x86 with i386 support and several network cards",
+			info: types.InstanceTypeInfo{
+				InstanceType:  types.InstanceTypeT2Micro,
+				Hypervisor:    types.InstanceTypeHypervisorXen,
+				ProcessorInfo: &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{types.ArchitectureTypeI386, types.ArchitectureTypeX8664}},
+				VCpuInfo:      &types.VCpuInfo{DefaultVCpus: aws.Int32(1)},
+				MemoryInfo:    &types.MemoryInfo{SizeInMiB: aws.Int64(1024)},
+				EbsInfo:       &types.EbsInfo{EbsOptimizedSupport: types.EbsOptimizedSupportUnsupported},
+				NetworkInfo: &types.NetworkInfo{
+					MaximumNetworkInterfaces:  aws.Int32(4),
+					Ipv4AddressesPerInterface: aws.Int32(2),
+					DefaultNetworkCardIndex:   aws.Int32(0),
+					NetworkCards: []types.NetworkCardInfo{
+						{NetworkCardIndex: aws.Int32(0), MaximumNetworkInterfaces: aws.Int32(2)},
+						{NetworkCardIndex: aws.Int32(1), MaximumNetworkInterfaces: aws.Int32(2)},
+					},
+				},
+			},
+			want: InstanceTypeAttributes{
+				Architecture:   "x86_64",
+				VCpus:          1,
+				MemoryMiB:      1024,
+				MaxENIs:        2,
+				IPv4PerENI:     2,
+				EbsOptimized:   false,
+				HypervisorType: "xen",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetInstanceTypeAttributes(tt.info); got != tt.want {
+				t.Errorf("GetInstanceTypeAttributes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetInstanceTypesFromRequirements(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{
+		instanceTypeInfo("m7g.xlarge", 4, 16384),
+		instanceTypeInfo("c7g.large", 2, 4096),
+		instanceTypeInfo("m7g.large", 2, 8192),
+	}
+
+	tests := []struct {
+		name          string
+		requirements  InstanceRequirements
+		wantArchs     []types.ArchitectureType
+		wantVCpuMax   *int32
+		wantBurstable types.BurstablePerformance
+		want          []string
+	}{
+		{
+			name: "Graviton 2-4 vCPUs, no burstable",
+			requirements: InstanceRequirements{
+				VCpuMin:              2,
+				VCpuMax:              4,
+				MemoryMiBMin:         4096,
+				ArchitectureTypes:    []string{"arm64"},
+				BurstablePerformance: "excluded",
+				InstanceGenerations:  []string{"current"},
+			},
+			wantArchs:     []types.ArchitectureType{types.ArchitectureTypeArm64},
+			wantVCpuMax:   aws.Int32(4),
+			wantBurstable: types.BurstablePerformanceExcluded,
+			want:          []string{"c7g.large", "m7g.large", "m7g.xlarge"},
+		},
+		{
+			name:         "Defaults to both architectures and no maximum",
+			requirements: InstanceRequirements{VCpuMin: 2},
+			wantArchs:    []types.ArchitectureType{types.ArchitectureTypeX8664, types.ArchitectureTypeArm64},
+			want:         []string{"c7g.large", "m7g.large", "m7g.xlarge"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2Client := newRegionMock(catalogue, nil)
+			mockEC2Client.mockGetInstanceTypesFromInstanceRequirements = func(ctx context.Context, params *ec2.GetInstanceTypesFromInstanceRequirementsInput, optFns ...func(*ec2.Options)) (*ec2.GetInstanceTypesFromInstanceRequirementsOutput, error) {
+				if !reflect.DeepEqual(params.ArchitectureTypes, tt.wantArchs) {
+					t.Errorf("ArchitectureTypes = %v, want %v", params.ArchitectureTypes, tt.wantArchs)
+				}
+				if !reflect.DeepEqual(params.InstanceRequirements.VCpuCount.Max, tt.wantVCpuMax) {
+					t.Errorf("VCpuCount.Max = %v, want %v", params.InstanceRequirements.VCpuCount.Max, tt.wantVCpuMax)
+				}
+				if params.InstanceRequirements.BurstablePerformance != tt.wantBurstable {
+					t.Errorf("BurstablePerformance = %v, want %v", params.InstanceRequirements.BurstablePerformance, tt.wantBurstable)
+				}
+				if params.NextToken == nil {
+					return &ec2.GetInstanceTypesFromInstanceRequirementsOutput{
+						InstanceTypes: []types.InstanceTypeInfoFromInstanceRequirements{
+							{InstanceType: aws.String("m7g.xlarge")},
+						},
+						NextToken: aws.String("page-2"),
+					}, nil
+				}
+				return &ec2.GetInstanceTypesFromInstanceRequirementsOutput{
+					InstanceTypes: []types.InstanceTypeInfoFromInstanceRequirements{
+						{InstanceType: aws.String("c7g.large")},
+						{InstanceType: aws.String("m7g.large")},
+					},
+				}, nil
+			}
+
+			infos, err := GetInstanceTypesFromRequirements(context.Background(), tt.requirements, mockEC2Client)
+			if err != nil {
+				t.Errorf("GetInstanceTypesFromRequirements() error = %v", err)
+				return
+			}
+			got := make([]string, 0, len(infos))
+			for _, info := range infos {
+				got = append(got, string(info.InstanceType))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstanceTypesFromRequirements() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
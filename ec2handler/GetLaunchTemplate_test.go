@@ -0,0 +1,125 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetLaunchTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		data    *types.ResponseLaunchTemplateData
+		want    LaunchTemplate
+		wantErr bool
+	}{
+		{
+			name: "Instance type with a network interface",
+			data: &types.ResponseLaunchTemplateData{
+				InstanceType:       types.InstanceTypeM7iLarge,
+				ImageId:            aws.String("ami-123"),
+				KeyName:            aws.String("ops"),
+				IamInstanceProfile: &types.LaunchTemplateIamInstanceProfileSpecification{Name: aws.String("app")},
+				NetworkInterfaces: []types.LaunchTemplateInstanceNetworkInterfaceSpecification{
+					{DeviceIndex: aws.Int32(0), SubnetId: aws.String("subnet-a"), Groups: []string{"sg-1"}},
+				},
+				Placement: &types.LaunchTemplatePlacement{AvailabilityZone: aws.String("us-east-1a")},
+			},
+			want: LaunchTemplate{
+				LaunchTemplateId:   "lt-app",
+				Version:            "7",
+				InstanceType:       "m7i.large",
+				ImageId:            "ami-123",
+				KeyName:            "ops",
+				SecurityGroupIds:   []string{"sg-1"},
+				IamInstanceProfile: "app",
+				SubnetId:           "subnet-a",
+				AvailabilityZone:   "us-east-1a",
+				NetworkInterfaces:  true,
+			},
+		},
+		{
+			name:    "Instance requirements",
+			version: "$Latest",
+			data: &types.ResponseLaunchTemplateData{
+				InstanceRequirements: &types.InstanceRequirements{
+					VCpuCount:           &types.VCpuCountRange{Min: aws.Int32(2), Max: aws.Int32(4)},
+					MemoryMiB:           &types.MemoryMiB{Min: aws.Int32(4096)},
+					InstanceGenerations: []types.InstanceGeneration{types.InstanceGenerationCurrent},
+				},
+				SecurityGroupIds: []string{"sg-2"},
+			},
+			want: LaunchTemplate{
+				LaunchTemplateId: "lt-app",
+				Version:          "7",
+				Requirements: &InstanceRequirements{
+					VCpuMin:             2,
+					VCpuMax:             4,
+					MemoryMiBMin:        4096,
+					InstanceGenerations: []string{"current"},
+				},
+				SecurityGroupIds: []string{"sg-2"},
+			},
+		},
+		{
+			name:    "Missing version",
+			version: "99",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2Client := &MockEC2Client{
+				mockDescribeLaunchTemplateVersions: func(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+					want := tt.version
+					if want == "" {
+						want = "$Default"
+					}
+					if !reflect.DeepEqual(params.Versions, []string{want}) || !aws.ToBool(params.ResolveAlias) {
+						t.Errorf("DescribeLaunchTemplateVersions() input = %+v", params)
+					}
+					if tt.data == nil {
+						return &ec2.DescribeLaunchTemplateVersionsOutput{}, nil
+					}
+					return &ec2.DescribeLaunchTemplateVersionsOutput{
+						LaunchTemplateVersions: []types.LaunchTemplateVersion{
+							{LaunchTemplateId: params.LaunchTemplateId, VersionNumber: aws.Int64(7), LaunchTemplateData: tt.data},
+						},
+					}, nil
+				},
+			}
+			got, err := GetLaunchTemplate(context.Background(), "lt-app", tt.version, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLaunchTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetLaunchTemplate() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaunchTemplateApply(t *testing.T) {
+	template := LaunchTemplate{
+		InstanceType:     "m7i.large",
+		ImageId:          "ami-123",
+		SecurityGroupIds: []string{"sg-1"},
+		SubnetId:         "subnet-a",
+	}
+	got := template.apply(AZCheckInput{ImageId: "ami-override"})
+	want := AZCheckInput{
+		InstanceTypes:    []string{"m7i.large"},
+		Subnets:          []string{"subnet-a"},
+		ImageId:          "ami-override",
+		SecurityGroupIds: []string{"sg-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() got = %+v, want %+v", got, want)
+	}
+}
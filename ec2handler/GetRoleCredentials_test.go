@@ -0,0 +1,84 @@
+package ec2handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
+)
+
+// MockAssumeRoleClient is a mock implementation of the stscreds.AssumeRoleAPIClient interface
+type MockAssumeRoleClient struct {
+	mockAssumeRole func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+func (m *MockAssumeRoleClient) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	return m.mockAssumeRole(ctx, params, optFns...)
+}
+
+func TestGetRoleCredentials(t *testing.T) {
+	const (
+		workloadRole = "arn:aws:iam::123456789012:role/InstanceTypAZCheck"
+		deniedRole   = "arn:aws:iam::111122223333:role/InstanceTypAZCheck"
+	)
+	resetRoleCredentials()
+	t.Cleanup(resetRoleCredentials)
+
+	calls := 0
+	mockAssumeRoleClient := &MockAssumeRoleClient{
+		mockAssumeRole: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+			calls++
+			if aws.ToString(params.RoleArn) == deniedRole {
+				return nil, &smithy.GenericAPIError{Code: "AccessDenied", Message: "User is not authorized to perform: sts:AssumeRole"}
+			}
+			if aws.ToString(params.ExternalId) != "tooling" {
+				t.Errorf("AssumeRole() ExternalId = %v", aws.ToString(params.ExternalId))
+			}
+			return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+				AccessKeyId:     aws.String("AKIAWORKLOAD"),
+				SecretAccessKey: aws.String("secret"),
+				SessionToken:    aws.String("token"),
+				Expiration:      aws.Time(time.Now().Add(time.Hour)),
+			}}, nil
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		credentials, err := GetRoleCredentials(context.Background(), "us-east-1", workloadRole, "tooling", mockAssumeRoleClient)
+		if err != nil {
+			t.Fatalf("GetRoleCredentials() error = %v", err)
+		}
+		got, _ := credentials.Retrieve(context.Background())
+		if got.AccessKeyID != "AKIAWORKLOAD" {
+			t.Errorf("GetRoleCredentials() AccessKeyID = %v", got.AccessKeyID)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("AssumeRole() called %d times, want the credentials cached after 1", calls)
+	}
+
+	// Another region assumes the role through its own STS client
+	regionCalls := 0
+	regionClient := &MockAssumeRoleClient{
+		mockAssumeRole: func(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+			regionCalls++
+			return mockAssumeRoleClient.AssumeRole(ctx, params, optFns...)
+		},
+	}
+	if _, err := GetRoleCredentials(context.Background(), "eu-west-1", workloadRole, "tooling", regionClient); err != nil {
+		t.Fatalf("GetRoleCredentials() error = %v", err)
+	}
+	if regionCalls != 1 {
+		t.Errorf("AssumeRole() called %d times in eu-west-1, want 1", regionCalls)
+	}
+
+	_, err := GetRoleCredentials(context.Background(), "us-east-1", deniedRole, "", mockAssumeRoleClient)
+	if err == nil || !strings.Contains(err.Error(), "access denied assuming role "+deniedRole) {
+		t.Errorf("GetRoleCredentials() error = %v, want access denied", err)
+	}
+}
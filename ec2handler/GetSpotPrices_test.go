@@ -0,0 +1,115 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestGetSpotPrices(t *testing.T) {
+	now := time.Now()
+	mockEC2Client := &MockEC2Client{
+		mockDescribeSpotPriceHistory: func(ctx context.Context, params *ec2.DescribeSpotPriceHistoryInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
+			if !reflect.DeepEqual(params.ProductDescriptions, []string{"Linux/UNIX"}) {
+				t.Errorf("ProductDescriptions = %v, want [Linux/UNIX]", params.ProductDescriptions)
+			}
+			if params.NextToken == nil {
+				return &ec2.DescribeSpotPriceHistoryOutput{
+					SpotPriceHistory: []types.SpotPrice{
+						{AvailabilityZone: aws.String("us-east-1a"), SpotPrice: aws.String("0.0070"), Timestamp: aws.Time(now.Add(-time.Hour))},
+						{AvailabilityZone: aws.String("us-east-1b"), SpotPrice: aws.String("0.0052"), Timestamp: aws.Time(now.Add(-time.Hour))},
+					},
+					NextToken: aws.String("page-2"),
+				}, nil
+			}
+			return &ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []types.SpotPrice{
+					{AvailabilityZone: aws.String("us-east-1a"), SpotPrice: aws.String("0.0061"), Timestamp: aws.Time(now.Add(-time.Minute))},
+					{AvailabilityZone: aws.String("us-east-1b"), SpotPrice: aws.String("0.0049"), Timestamp: aws.Time(now.Add(-2 * time.Hour))},
+				},
+				NextToken: aws.String(""),
+			}, nil
+		},
+	}
+
+	got, err := GetSpotPrices(context.Background(), "t4g.small", "", []string{"us-east-1a", "us-east-1b"}, mockEC2Client)
+	if err != nil {
+		t.Errorf("GetSpotPrices() error = %v", err)
+		return
+	}
+	want := map[string]string{"us-east-1a": "0.0061", "us-east-1b": "0.0052"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSpotPrices() got = %v, want %v", got, want)
+	}
+}
+
+func TestOrderZones(t *testing.T) {
+	scores := map[string]int32{"us-east-1a": 3, "us-east-1b": 9, "us-east-1e": 7}
+	prices := map[string]string{"us-east-1a": "0.0061", "us-east-1b": "0.0070", "us-east-1e": "0.0061"}
+
+	tests := []struct {
+		name     string
+		strategy string
+		scores   map[string]int32
+		prices   map[string]string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "Default without scores keeps the order",
+			strategy: "",
+			want:     []string{"us-east-1a", "us-east-1b", "us-east-1e"},
+		},
+		{
+			name:     "Default with scores",
+			strategy: SelectionStrategyDefault,
+			scores:   scores,
+			want:     []string{"us-east-1b", "us-east-1e", "us-east-1a"},
+		},
+		{
+			name:     "Lowest spot price, ties by score",
+			strategy: SelectionStrategyLowestSpotPrice,
+			scores:   scores,
+			prices:   prices,
+			want:     []string{"us-east-1e", "us-east-1a", "us-east-1b"},
+		},
+		{
+			name:     "Zones without a price go last",
+			strategy: SelectionStrategyLowestSpotPrice,
+			prices:   map[string]string{"us-east-1e": "0.0080"},
+			want:     []string{"us-east-1e", "us-east-1a", "us-east-1b"},
+		},
+		{
+			name:     "Spot placement score needs scores",
+			strategy: SelectionStrategySpotPlacementScore,
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown strategy",
+			strategy: "cheapest",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones := []string{"us-east-1a", "us-east-1b", "us-east-1e"}
+			err := OrderZones(zones, tt.strategy, tt.scores, tt.prices)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OrderZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(zones, tt.want) {
+				t.Errorf("OrderZones() got = %v, want %v", zones, tt.want)
+			}
+		})
+	}
+	// With no zones there is nothing to score, so the strategy can't fail
+	if err := OrderZones(nil, SelectionStrategySpotPlacementScore, nil, nil); err != nil {
+		t.Errorf("OrderZones() with no zones error = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/outposts"
+	optypes "github.com/aws/aws-sdk-go-v2/service/outposts/types"
+)
+
+// MockOutpostsClient is a mock implementation of the OutpostsClient interface
+type MockOutpostsClient struct {
+	mockGetOutpostInstanceTypes func(ctx context.Context, params *outposts.GetOutpostInstanceTypesInput, optFns ...func(*outposts.Options)) (*outposts.GetOutpostInstanceTypesOutput, error)
+}
+
+func (m *MockOutpostsClient) GetOutpostInstanceTypes(ctx context.Context, params *outposts.GetOutpostInstanceTypesInput, optFns ...func(*outposts.Options)) (*outposts.GetOutpostInstanceTypesOutput, error) {
+	return m.mockGetOutpostInstanceTypes(ctx, params, optFns...)
+}
+
+func TestGetSubnetZones(t *testing.T) {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+		{SubnetId: aws.String("subnet-lz"), AvailabilityZone: aws.String("us-east-1-bos-1a")},
+		{SubnetId: aws.String("subnet-op"), AvailabilityZone: aws.String("us-east-1a"), OutpostArn: aws.String(testOutpostArn)},
+	}
+
+	got, err := GetSubnetZones(context.Background(), subnets, newRegionMock(nil, nil))
+	if err != nil {
+		t.Fatalf("GetSubnetZones() error = %v", err)
+	}
+	want := map[string]SubnetZone{
+		"subnet-a":  {ZoneName: "us-east-1a", ZoneType: "availability-zone"},
+		"subnet-lz": {ZoneName: "us-east-1-bos-1a", ZoneType: "local-zone", ParentZoneName: "us-east-1b"},
+		"subnet-op": {ZoneName: "us-east-1a", ZoneType: ZoneTypeOutpost, ParentZoneName: "us-east-1a", OutpostArn: testOutpostArn},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSubnetZones() = %v, want %v", got, want)
+	}
+}
+
+func TestGetZoneIds(t *testing.T) {
+	tests := []struct {
+		name    string
+		zones   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "Zones in order",
+			zones: []string{"us-east-1e", "us-east-1a"},
+			want:  []string{"use1-az3", "use1-az6"},
+		},
+		{
+			name:    "Zone not in the region",
+			zones:   []string{"us-east-1a", "eu-west-1a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetZoneIds(context.Background(), tt.zones, newRegionMock(nil, nil))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetZoneIds() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetZoneIds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOutpostInstanceTypes(t *testing.T) {
+	mockOutpostsClient := &MockOutpostsClient{
+		mockGetOutpostInstanceTypes: func(ctx context.Context, params *outposts.GetOutpostInstanceTypesInput, optFns ...func(*outposts.Options)) (*outposts.GetOutpostInstanceTypesOutput, error) {
+			if aws.ToString(params.OutpostId) != testOutpostArn {
+				t.Errorf("GetOutpostInstanceTypes() OutpostId = %v", aws.ToString(params.OutpostId))
+			}
+			if params.NextToken == nil {
+				return &outposts.GetOutpostInstanceTypesOutput{
+					InstanceTypes: []optypes.InstanceTypeItem{{InstanceType: aws.String("m5.large")}},
+					NextToken:     aws.String("page-2"),
+				}, nil
+			}
+			return &outposts.GetOutpostInstanceTypesOutput{
+				InstanceTypes: []optypes.InstanceTypeItem{{InstanceType: aws.String("c5.xlarge")}},
+			}, nil
+		},
+	}
+
+	got, err := GetOutpostInstanceTypes(context.Background(), testOutpostArn, mockOutpostsClient)
+	if err != nil {
+		t.Fatalf("GetOutpostInstanceTypes() error = %v", err)
+	}
+	if want := []string{"m5.large", "c5.xlarge"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetOutpostInstanceTypes() = %v, want %v", got, want)
+	}
+}
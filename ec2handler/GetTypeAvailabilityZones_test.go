@@ -2,12 +2,20 @@ package ec2handler
 
 import (
 	"context"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/outposts"
+	optypes "github.com/aws/aws-sdk-go-v2/service/outposts/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 	"github.com/golang/mock/gomock"
 )
 
@@ -149,7 +157,12 @@ func TestGetTypeAvailabilityZones(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup()
-			gotPhysicalResourceId, gotAzInfo, gotSubnetInfo, gotFirstSubnet, gotFirstAZ, gotNextIP, err := GetTypeAvailabilityZones(tt.args.ctx, tt.args.instanceType, tt.args.subnets)
+			got, err := GetTypeAvailabilityZones(tt.args.ctx, AZCheckInput{
+				InstanceTypes: []string{tt.args.instanceType},
+				Subnets:       tt.args.subnets,
+			})
+			gotPhysicalResourceId, gotAzInfo, gotSubnetInfo := got.PhysicalResourceId, got.AvailableZones, got.AvailableSubnets
+			gotFirstSubnet, gotFirstAZ, gotNextIP := got.FirstSubnetId, got.FirstAZ, got.NextIP
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetTypeAvailabilityZones() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -172,7 +185,875 @@ func TestGetTypeAvailabilityZones(t *testing.T) {
 			if gotNextIP != tt.wantNextIP {
 				t.Errorf("GetTypeAvailabilityZones() gotNextIP = %v, want %v", gotNextIP, tt.wantNextIP)
 			}
+		})
+	}
+}
+
+// filterValues - the values of the named filter
+func filterValues(filters []types.Filter, name string) []string {
+	for _, filter := range filters {
+		if aws.ToString(filter.Name) == name {
+			return filter.Values
+		}
+	}
+	return nil
+}
+
+// matchesAny - true if the value matches one of the EC2 filter patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// testAccountId is the account the checks are made from, testOwnerId the account that shares subnet-s and subnet-x
+const (
+	testAccountId = "123456789012"
+	testOwnerId   = "111122223333"
+)
+
+// testOutpostArn is the Outpost subnet-op is on
+const testOutpostArn = "arn:aws:outposts:us-east-1:123456789012:outpost/op-0123456789abcdef0"
+
+// newRegionMock - a MockEC2Client for a region with three subnets (us-east-1a, us-east-1b and us-east-1e), one in
+// the us-east-1-bos-1a Local Zone, one on an Outpost in us-east-1a and two shared from testOwnerId (subnet-s in
+// us-east-1e, and subnet-x in us-east-1b, which the account can't use), the given instance type catalogue and the
+// zones each type is offered in. The offerings' zone names are those of the default mapping of zone IDs to names.
+func newRegionMock(catalogue []types.InstanceTypeInfo, offered map[string][]string) *MockEC2Client {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-a"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1a"), AvailabilityZoneId: aws.String("use1-az6"), CidrBlock: aws.String("10.0.0.0/24")},
+		{SubnetId: aws.String("subnet-b"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1b"), AvailabilityZoneId: aws.String("use1-az1"), CidrBlock: aws.String("10.0.1.0/24")},
+		{SubnetId: aws.String("subnet-e"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1e"), AvailabilityZoneId: aws.String("use1-az3"), CidrBlock: aws.String("10.0.2.0/24")},
+		{SubnetId: aws.String("subnet-lz"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1-bos-1a"), AvailabilityZoneId: aws.String("use1-bos1-az1"), CidrBlock: aws.String("10.0.3.0/24")},
+		{SubnetId: aws.String("subnet-op"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1a"), AvailabilityZoneId: aws.String("use1-az6"), CidrBlock: aws.String("10.0.4.0/24"), OutpostArn: aws.String(testOutpostArn)},
+		{SubnetId: aws.String("subnet-s"), OwnerId: aws.String(testOwnerId), AvailabilityZone: aws.String("us-east-1e"), AvailabilityZoneId: aws.String("use1-az3"), CidrBlock: aws.String("10.0.5.0/24")},
+		{SubnetId: aws.String("subnet-x"), OwnerId: aws.String(testOwnerId), AvailabilityZone: aws.String("us-east-1b"), AvailabilityZoneId: aws.String("use1-az1"), CidrBlock: aws.String("10.0.6.0/24")},
+	}
+	zoneNames := map[string]string{"use1-az6": "us-east-1a", "use1-az1": "us-east-1b", "use1-az3": "us-east-1e", "use1-bos1-az1": "us-east-1-bos-1a"}
+	zones := []types.AvailabilityZone{
+		{ZoneName: aws.String("us-east-1a"), ZoneId: aws.String("use1-az6"), ZoneType: aws.String("availability-zone")},
+		{ZoneName: aws.String("us-east-1b"), ZoneId: aws.String("use1-az1"), ZoneType: aws.String("availability-zone")},
+		{ZoneName: aws.String("us-east-1e"), ZoneId: aws.String("use1-az3"), ZoneType: aws.String("availability-zone")},
+		{ZoneName: aws.String("us-east-1-bos-1a"), ZoneId: aws.String("use1-bos1-az1"), ZoneType: aws.String("local-zone"), ParentZoneName: aws.String("us-east-1b")},
+	}
+	return &MockEC2Client{
+		mockDescribeSubnets: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			ids := append(filterValues(params.Filters, "subnet-id"), params.SubnetIds...)
+			output := &ec2.DescribeSubnetsOutput{}
+			for _, subnet := range subnets {
+				if matchesAny(ids, *subnet.SubnetId) {
+					output.Subnets = append(output.Subnets, subnet)
+				}
+			}
+			return output, nil
+		},
+		mockDescribeAvailabilityZones: func(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
+			output := &ec2.DescribeAvailabilityZonesOutput{}
+			for _, zone := range zones {
+				if matchesAny(params.ZoneNames, *zone.ZoneName) {
+					output.AvailabilityZones = append(output.AvailabilityZones, zone)
+				}
+			}
+			return output, nil
+		},
+		mockDescribeInstanceTypes: func(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+			patterns := filterValues(params.Filters, "instance-type")
+			for _, instanceType := range params.InstanceTypes {
+				patterns = append(patterns, string(instanceType))
+			}
+			vcpus := filterValues(params.Filters, "vcpu-info.default-vcpus")
+			memory := filterValues(params.Filters, "memory-info.size-in-mib")
+			output := &ec2.DescribeInstanceTypesOutput{}
+			for _, info := range catalogue {
+				if len(patterns) > 0 && !matchesAny(patterns, string(info.InstanceType)) {
+					continue
+				}
+				if len(vcpus) > 0 && !matchesAny(vcpus, fmt.Sprint(defaultVCpus(info))) {
+					continue
+				}
+				if len(memory) > 0 && !matchesAny(memory, fmt.Sprint(memoryMiB(info))) {
+					continue
+				}
+				output.InstanceTypes = append(output.InstanceTypes, info)
+			}
+			return output, nil
+		},
+		mockDescribeInstanceTypeOfferings: func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+			patterns := filterValues(params.Filters, "instance-type")
+			locations := filterValues(params.Filters, "location")
+			output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+			for _, info := range catalogue {
+				if !matchesAny(patterns, string(info.InstanceType)) {
+					continue
+				}
+				for _, zone := range offered[string(info.InstanceType)] {
+					if params.LocationType != types.LocationTypeAvailabilityZoneId {
+						if matchesAny(locations, zone) {
+							output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, offering(string(info.InstanceType), zone))
+						}
+						continue
+					}
+					for _, zoneId := range locations {
+						if zoneNames[zoneId] == zone {
+							output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, types.InstanceTypeOffering{
+								InstanceType: info.InstanceType,
+								Location:     aws.String(zoneId),
+								LocationType: types.LocationTypeAvailabilityZoneId,
+							})
+						}
+					}
+				}
+			}
+			return output, nil
+		},
+		mockCreateNetworkInterface: func(ctx context.Context, params *ec2.CreateNetworkInterfaceInput, optFns ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error) {
+			if aws.ToString(params.SubnetId) == "subnet-x" {
+				return nil, &smithy.GenericAPIError{Code: "InvalidGroup.NotFound", Message: "The security group does not exist in the VPC."}
+			}
+			return nil, &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}
+		},
+		mockDescribeNetworkInterfaces: func(ctx context.Context, params *ec2.DescribeNetworkInterfacesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeNetworkInterfacesOutput, error) {
+			output := &ec2.DescribeNetworkInterfacesOutput{}
+			if ip := filterValues(params.Filters, "private-ip-address"); len(ip) == 1 && strings.HasSuffix(ip[0], ".4") {
+				output.NetworkInterfaces = []types.NetworkInterface{{PrivateIpAddress: aws.String(ip[0])}}
+			}
+			return output, nil
+		},
+	}
+}
+
+func TestGetTypeAvailabilityZonesWithClient(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{
+		instanceTypeInfo("t4g.small", 2, 2048),
+		instanceTypeInfo("t3.small", 2, 2048),
+		instanceTypeInfo("c7g.large", 2, 4096),
+		instanceTypeInfo("c7g.xlarge", 4, 8192),
+		instanceTypeInfo("c6g.large", 2, 4096),
+	}
+	offered := map[string][]string{
+		"t4g.small":  {"us-east-1a", "us-east-1b"},
+		"t3.small":   {"us-east-1a", "us-east-1b", "us-east-1e", "us-east-1-bos-1a"},
+		"c7g.large":  {"us-east-1b"},
+		"c7g.xlarge": {"us-east-1a", "us-east-1b"},
+	}
+	subnets := []string{"subnet-a", "subnet-b", "subnet-e"}
+	mockSTSClient := &MockSTSClient{
+		mockGetCallerIdentity: func(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+			return &sts.GetCallerIdentityOutput{Account: aws.String(testAccountId)}, nil
+		},
+	}
+	mockOutpostsClient := &MockOutpostsClient{
+		mockGetOutpostInstanceTypes: func(ctx context.Context, params *outposts.GetOutpostInstanceTypesInput, optFns ...func(*outposts.Options)) (*outposts.GetOutpostInstanceTypesOutput, error) {
+			return &outposts.GetOutpostInstanceTypesOutput{
+				InstanceTypes: []optypes.InstanceTypeItem{{InstanceType: aws.String("t3.small")}},
+			}, nil
+		},
+	}
+	// subnetZones - the zones of the subnets in availability zones
+	subnetZones := func(subnets ...string) map[string]SubnetZone {
+		zones := make(map[string]SubnetZone, len(subnets))
+		for _, subnet := range subnets {
+			zones[subnet] = SubnetZone{ZoneName: "us-east-1" + strings.TrimPrefix(subnet, "subnet-"), ZoneType: "availability-zone"}
+		}
+		return zones
+	}
+	// subnetOwners - the subnets owned by the account
+	subnetOwners := func(subnets ...string) map[string]string {
+		owners := make(map[string]string, len(subnets))
+		for _, subnet := range subnets {
+			owners[subnet] = testAccountId
+		}
+		return owners
+	}
+
+	tests := []struct {
+		name    string
+		input   AZCheckInput
+		want    AZCheckResult
+		wantErr bool
+		// wantErrText are what the error must mention
+		wantErrText []string
+	}{
+		{
+			name:  "Single type",
+			input: AZCheckInput{InstanceTypes: []string{"t4g.small"}, Subnets: subnets},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t4g.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t4g.small",
+				MatchedInstanceTypes: []string{"t4g.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t4g.small"},
+					"us-east-1b": {"t4g.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "arm64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{"t3.small"},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t4g.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a", "subnet-b"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a", "subnet-b"),
+			},
+		},
+		{
+			name:  "Family pattern",
+			input: AZCheckInput{InstanceTypes: []string{"c7g.*large"}, Subnets: subnets},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-c7g.*large-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "c7g.xlarge",
+				MatchedInstanceTypes: []string{"c7g.large", "c7g.xlarge"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"c7g.xlarge"},
+					"us-east-1b": {"c7g.large", "c7g.xlarge"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "arm64", VCpus: 4, MemoryMiB: 8192},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "c7g.xlarge", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a", "subnet-b"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a", "subnet-b"),
+			},
+		},
+		{
+			name:  "Fallback type offered in more zones",
+			input: AZCheckInput{InstanceTypes: []string{"t4g.small", "t3.small"}, Subnets: subnets},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t4g.small-t3.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-b", "subnet-e"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t4g.small", "t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t4g.small", "t3.small"},
+					"us-east-1b": {"t4g.small", "t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a", "subnet-b", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a", "subnet-b", "subnet-e"),
+			},
+		},
+		{
+			name: "Instance requirements",
+			input: AZCheckInput{
+				Requirements: &InstanceRequirements{VCpuMin: 2, VCpuMax: 2, MemoryMiBMin: 2048, MemoryMiBMax: 4096},
+				Subnets:      subnets,
+			},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-InstanceRequirements-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-b", "subnet-e"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small", "t4g.small", "c7g.large"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small", "t4g.small"},
+					"us-east-1b": {"t3.small", "t4g.small", "c7g.large"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a", "subnet-b", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a", "subnet-b", "subnet-e"),
+			},
+		},
+		{
+			name:  "Image rules out the Graviton type",
+			input: AZCheckInput{InstanceTypes: []string{"t4g.small", "t3.small"}, Subnets: subnets[:2], ImageId: "ami-x86"},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t4g.small-t3.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a", "subnet-b"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a", "subnet-b"),
+			},
+		},
+		{
+			name:    "Image compatible with none of the types",
+			input:   AZCheckInput{InstanceTypes: []string{"t4g.small"}, Subnets: subnets, ImageId: "ami-x86"},
+			wantErr: true,
+		},
+		{
+			name:  "Spot placement scores",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, SpotTargetCapacity: 4, Region: "us-east-1"},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1e", "us-east-1b", "us-east-1a"},
+				AvailableSubnets:     []string{"subnet-e", "subnet-b", "subnet-a"},
+				FirstSubnetId:        "subnet-e",
+				FirstAZ:              "us-east-1e",
+				NextIP:               "10.0.2.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-e", "subnet-b", "subnet-a"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-e", "subnet-b", "subnet-a"),
+				SpotPlacementScores: map[string]int32{
+					"us-east-1a": 3,
+					"us-east-1b": 7,
+					"us-east-1e": 9,
+				},
+			},
+		},
+		{
+			name:  "Spot placement scores for a type offered in no zone",
+			input: AZCheckInput{InstanceTypes: []string{"c6g.large"}, Subnets: subnets, MinimumAZs: 1, SpotTargetCapacity: 4, SelectionStrategy: SelectionStrategySpotPlacementScore},
+			want: AZCheckResult{
+				PhysicalResourceId:      "InstanceTypAZCheck-c6g.large-",
+				MatchedInstanceTypes:    []string{},
+				InstanceTypesByAZ:       map[string][]string{},
+				EquivalentInstanceTypes: []string{"c7g.large"},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{},
+				Tenancy:                 "default",
+				SubnetZones:             map[string]SubnetZone{},
+				AccountId:               testAccountId,
+				SubnetOwners:            map[string]string{},
+			},
+		},
+		{
+			name:  "Capacity reservation preferred",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, CheckCapacityReservations: true},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1b", "us-east-1a", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-b", "subnet-a", "subnet-e"},
+				FirstSubnetId:        "subnet-b",
+				FirstAZ:              "us-east-1b",
+				NextIP:               "10.0.1.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-b", "subnet-a", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-b", "subnet-a", "subnet-e"),
+				CapacityReservations: map[string]CapacityReservation{
+					"us-east-1b": {CapacityReservationId: "cr-b", AvailableInstanceCount: 2, OwnerId: "111122223333"},
+				},
+			},
+		},
+		{
+			name:  "Capacity reservation required",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, RequireCapacityReservation: true},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1b"},
+				AvailableSubnets:     []string{"subnet-b"},
+				FirstSubnetId:        "subnet-b",
+				FirstAZ:              "us-east-1b",
+				NextIP:               "10.0.1.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-b"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-b"),
+				CapacityReservations: map[string]CapacityReservation{
+					"us-east-1b": {CapacityReservationId: "cr-b", AvailableInstanceCount: 2, OwnerId: "111122223333"},
+				},
+			},
+		},
+		{
+			name: "DryRun launch rejected in one subnet",
+			input: AZCheckInput{
+				InstanceTypes: []string{"t3.small"},
+				Subnets:       subnets,
+				ImageId:       "ami-x86",
+				DryRunLaunch:  true,
+			},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-b", "subnet-e"},
+				FirstSubnetId:        "subnet-b",
+				FirstAZ:              "us-east-1b",
+				NextIP:               "10.0.1.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-b", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-b", "subnet-e"),
+				DryRunFailures:          map[string]string{"subnet-a": "InvalidParameterCombination: not supported in this subnet"},
+			},
+		},
+		{
+			name: "DryRun launch rejected in a zone's subnet but not its Outpost subnet",
+			input: AZCheckInput{
+				InstanceTypes: []string{"t3.small"},
+				Subnets:       []string{"subnet-a", "subnet-op"},
+				ImageId:       "ami-x86",
+				DryRunLaunch:  true,
+			},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a"},
+				AvailableSubnets:     []string{"subnet-op"},
+				FirstSubnetId:        "subnet-op",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.4.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-op": {ZoneName: "us-east-1a", ZoneType: "outpost", ParentZoneName: "us-east-1a", OutpostArn: testOutpostArn},
+				},
+				AccountId:      testAccountId,
+				SubnetOwners:   subnetOwners("subnet-op"),
+				DryRunFailures: map[string]string{"subnet-a": "InvalidParameterCombination: not supported in this subnet"},
+			},
+		},
+		{
+			name: "Launch template",
+			input: AZCheckInput{
+				LaunchTemplateId: "lt-app",
+				Subnets:          subnets,
+				DryRunLaunch:     true,
+			},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-b", "subnet-e"},
+				FirstSubnetId:        "subnet-b",
+				FirstAZ:              "us-east-1b",
+				NextIP:               "10.0.1.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-b", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-b", "subnet-e"),
+				DryRunFailures:          map[string]string{"subnet-a": "InvalidParameterCombination: not supported in this subnet"},
+				LaunchTemplateVersion:   "3",
+			},
+		},
+		{
+			name:  "Restricted to zone IDs",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, ZoneIds: []string{"use1-az1", "use1-az3"}},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-b", "subnet-e"},
+				FirstSubnetId:        "subnet-b",
+				FirstAZ:              "us-east-1b",
+				NextIP:               "10.0.1.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-b", "subnet-e"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-b", "subnet-e"),
+			},
+		},
+		{
+			name:    "No type can drive the EBS volume",
+			input:   AZCheckInput{InstanceTypes: []string{"t3.small", "t4g.small"}, Subnets: subnets, VolumeType: "io2", Iops: 20000},
+			wantErr: true,
+		},
+		{
+			name:  "Cluster placement group",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, PlacementGroupStrategy: "cluster"},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a"},
+				AvailableSubnets:     []string{"subnet-a"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones:             subnetZones("subnet-a"),
+				AccountId:               testAccountId,
+				SubnetOwners:            subnetOwners("subnet-a"),
+			},
+		},
+		{
+			name:  "Cluster placement group with an Outpost subnet in the zone",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-a", "subnet-b", "subnet-op"}, PlacementGroupStrategy: "cluster"},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-op"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-a":  {ZoneName: "us-east-1a", ZoneType: "availability-zone"},
+					"subnet-op": {ZoneName: "us-east-1a", ZoneType: "outpost", ParentZoneName: "us-east-1a", OutpostArn: testOutpostArn},
+				},
+				AccountId:    testAccountId,
+				SubnetOwners: subnetOwners("subnet-a", "subnet-op"),
+			},
+		},
+		{
+			name:    "No type has EFA",
+			input:   AZCheckInput{InstanceTypes: []string{"t3.small", "t4g.small"}, Subnets: subnets, RequireEFA: true},
+			wantErr: true,
+		},
+		{
+			name:    "No type supports host tenancy",
+			input:   AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, Tenancy: "host"},
+			wantErr: true,
+		},
+		{
+			name:  "Local Zone and Outpost subnets",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-a", "subnet-lz", "subnet-op"}},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1-bos-1a"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-lz", "subnet-op"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a":       {"t3.small"},
+					"us-east-1-bos-1a": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-a":  {ZoneName: "us-east-1a", ZoneType: "availability-zone"},
+					"subnet-lz": {ZoneName: "us-east-1-bos-1a", ZoneType: "local-zone", ParentZoneName: "us-east-1b"},
+					"subnet-op": {ZoneName: "us-east-1a", ZoneType: "outpost", ParentZoneName: "us-east-1a", OutpostArn: testOutpostArn},
+				},
+				AccountId:    testAccountId,
+				SubnetOwners: subnetOwners("subnet-a", "subnet-lz", "subnet-op"),
+			},
+		},
+		{
+			name:  "Only an Outpost subnet",
+			input: AZCheckInput{InstanceTypes: []string{"t4g.small", "t3.small"}, Subnets: []string{"subnet-op"}},
+			want: AZCheckResult{
+				PhysicalResourceId:      "InstanceTypAZCheck-t4g.small-t3.small-",
+				AvailableZones:          []string{"us-east-1a"},
+				AvailableSubnets:        []string{"subnet-op"},
+				FirstSubnetId:           "subnet-op",
+				FirstAZ:                 "us-east-1a",
+				NextIP:                  "10.0.4.5",
+				SelectedInstanceType:    "t3.small",
+				MatchedInstanceTypes:    []string{"t3.small"},
+				InstanceTypesByAZ:       map[string][]string{},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-op": {ZoneName: "us-east-1a", ZoneType: "outpost", ParentZoneName: "us-east-1a", OutpostArn: testOutpostArn},
+				},
+				AccountId:    testAccountId,
+				SubnetOwners: subnetOwners("subnet-op"),
+			},
+		},
+		{
+			name:  "Shared subnets",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-a", "subnet-s", "subnet-x"}, SecurityGroupIds: []string{"sg-app"}},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-s"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-a": {ZoneName: "us-east-1a", ZoneType: "availability-zone"},
+					"subnet-s": {ZoneName: "us-east-1e", ZoneType: "availability-zone"},
+				},
+				AccountId:            testAccountId,
+				SubnetOwners:         map[string]string{"subnet-a": testAccountId, "subnet-s": testOwnerId},
+				SharedSubnetFailures: map[string]string{"subnet-x": "InvalidGroup.NotFound: The security group does not exist in the VPC."},
+			},
+		},
+		{
+			name:  "Shared subnets without security groups",
+			input: AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-a", "subnet-s", "subnet-x"}},
+			want: AZCheckResult{
+				PhysicalResourceId:   "InstanceTypAZCheck-t3.small-",
+				AvailableZones:       []string{"us-east-1a", "us-east-1b", "us-east-1e"},
+				AvailableSubnets:     []string{"subnet-a", "subnet-x", "subnet-s"},
+				FirstSubnetId:        "subnet-a",
+				FirstAZ:              "us-east-1a",
+				NextIP:               "10.0.0.5",
+				SelectedInstanceType: "t3.small",
+				MatchedInstanceTypes: []string{"t3.small"},
+				InstanceTypesByAZ: map[string][]string{
+					"us-east-1a": {"t3.small"},
+					"us-east-1b": {"t3.small"},
+					"us-east-1e": {"t3.small"},
+				},
+				Attributes:              InstanceTypeAttributes{Architecture: "x86_64", VCpus: 2, MemoryMiB: 2048},
+				EquivalentInstanceTypes: []string{},
+				LaunchTemplateOverrides: []LaunchTemplateOverride{{InstanceType: "t3.small", WeightedCapacity: "1"}},
+				Tenancy:                 "default",
+				SubnetZones: map[string]SubnetZone{
+					"subnet-a": {ZoneName: "us-east-1a", ZoneType: "availability-zone"},
+					"subnet-s": {ZoneName: "us-east-1e", ZoneType: "availability-zone"},
+					"subnet-x": {ZoneName: "us-east-1b", ZoneType: "availability-zone"},
+				},
+				AccountId:    testAccountId,
+				SubnetOwners: map[string]string{"subnet-a": testAccountId, "subnet-s": testOwnerId, "subnet-x": testOwnerId},
+			},
+		},
+		{
+			name:        "Subnet in another region",
+			input:       AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-a", "subnet-0eu"}, Region: "us-east-1"},
+			wantErr:     true,
+			wantErrText: []string{"subnet-0eu", "us-east-1"},
+		},
+		{
+			name:    "No usable shared subnet",
+			input:   AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: []string{"subnet-x"}, SecurityGroupIds: []string{"sg-app"}},
+			wantErr: true,
+		},
+		{
+			name:    "DryRun launch without an image",
+			input:   AZCheckInput{InstanceTypes: []string{"t3.small"}, Subnets: subnets, DryRunLaunch: true},
+			wantErr: true,
+		},
+		{
+			name:    "Capacity reservation required but none held",
+			input:   AZCheckInput{InstanceTypes: []string{"t4g.small"}, Subnets: subnets, RequireCapacityReservation: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEC2Client := newRegionMock(catalogue, offered)
+			mockEC2Client.mockRunInstances = func(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+				if tt.input.LaunchTemplateId != "" && aws.ToString(params.LaunchTemplate.Version) != "3" {
+					t.Errorf("RunInstances() LaunchTemplate = %+v", params.LaunchTemplate)
+				}
+				if aws.ToString(params.SubnetId) == "subnet-a" {
+					return nil, &smithy.GenericAPIError{Code: "InvalidParameterCombination", Message: "not supported in this subnet"}
+				}
+				return nil, &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}
+			}
+			mockEC2Client.mockDescribeLaunchTemplateVersions = func(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+				return &ec2.DescribeLaunchTemplateVersionsOutput{
+					LaunchTemplateVersions: []types.LaunchTemplateVersion{
+						{
+							LaunchTemplateId: params.LaunchTemplateId,
+							VersionNumber:    aws.Int64(3),
+							LaunchTemplateData: &types.ResponseLaunchTemplateData{
+								InstanceType: types.InstanceTypeT3Small,
+								ImageId:      aws.String("ami-x86"),
+							},
+						},
+					},
+				}, nil
+			}
+			mockEC2Client.mockDescribeCapacityReservations = func(ctx context.Context, params *ec2.DescribeCapacityReservationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error) {
+				if filterValues(params.Filters, "instance-type")[0] != "t3.small" {
+					return &ec2.DescribeCapacityReservationsOutput{}, nil
+				}
+				return &ec2.DescribeCapacityReservationsOutput{
+					CapacityReservations: []types.CapacityReservation{
+						{
+							CapacityReservationId:  aws.String("cr-b"),
+							AvailabilityZone:       aws.String("us-east-1b"),
+							AvailabilityZoneId:     aws.String("use1-az1"),
+							AvailableInstanceCount: aws.Int32(2),
+							OwnerId:                aws.String("111122223333"),
+						},
+					},
+				}, nil
+			}
+			mockEC2Client.mockGetSpotPlacementScores = func(ctx context.Context, params *ec2.GetSpotPlacementScoresInput, optFns ...func(*ec2.Options)) (*ec2.GetSpotPlacementScoresOutput, error) {
+				if !aws.ToBool(params.SingleAvailabilityZone) || aws.ToInt32(params.TargetCapacity) != 4 {
+					t.Errorf("GetSpotPlacementScores() input = %+v", params)
+				}
+				return &ec2.GetSpotPlacementScoresOutput{
+					SpotPlacementScores: []types.SpotPlacementScore{
+						{Region: aws.String("us-east-1"), AvailabilityZoneId: aws.String("use1-az6"), Score: aws.Int32(3)},
+						{Region: aws.String("us-east-1"), AvailabilityZoneId: aws.String("use1-az1"), Score: aws.Int32(7)},
+						{Region: aws.String("us-east-1"), AvailabilityZoneId: aws.String("use1-az3"), Score: aws.Int32(9)},
+					},
+				}, nil
+			}
+			mockEC2Client.mockDescribeImages = func(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+				return &ec2.DescribeImagesOutput{
+					Images: []types.Image{{ImageId: aws.String(params.ImageIds[0]), Architecture: types.ArchitectureValuesX8664}},
+				}, nil
+			}
+			mockEC2Client.mockGetInstanceTypesFromInstanceRequirements = func(ctx context.Context, params *ec2.GetInstanceTypesFromInstanceRequirementsInput, optFns ...func(*ec2.Options)) (*ec2.GetInstanceTypesFromInstanceRequirementsOutput, error) {
+				return &ec2.GetInstanceTypesFromInstanceRequirementsOutput{
+					InstanceTypes: []types.InstanceTypeInfoFromInstanceRequirements{
+						{InstanceType: aws.String("c7g.large")},
+						{InstanceType: aws.String("t3.small")},
+						{InstanceType: aws.String("t4g.small")},
+					},
+				}, nil
+			}
+			got, err := getTypeAvailabilityZones(context.Background(), tt.input, mockEC2Client, mockOutpostsClient, mockSTSClient)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getTypeAvailabilityZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				for _, text := range tt.wantErrText {
+					if !strings.Contains(err.Error(), text) {
+						t.Errorf("getTypeAvailabilityZones() error = %v, want it to mention %v", err, text)
+					}
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getTypeAvailabilityZones() got = %+v, want %+v", got, tt.want)
 			}
 		})
 	}
 }
+
+// TestGetTypeAvailabilityZonesZoneMapping - an account whose zone names are other zones than in the default mapping
+func TestGetTypeAvailabilityZonesZoneMapping(t *testing.T) {
+	// Offered in use1-az6 and use1-az1, us-east-1a and us-east-1b in the default mapping
+	offered := map[string][]string{"c7g.xlarge": {"us-east-1a", "us-east-1b"}}
+	mockEC2Client := newRegionMock([]types.InstanceTypeInfo{instanceTypeInfo("c7g.xlarge", 4, 8192)}, offered)
+	// The account's us-east-1a is use1-az3 and its us-east-1b is use1-az6
+	mockEC2Client.mockDescribeSubnets = func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+		return &ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{
+				{SubnetId: aws.String("subnet-a"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1a"), AvailabilityZoneId: aws.String("use1-az3"), CidrBlock: aws.String("10.0.0.0/24")},
+				{SubnetId: aws.String("subnet-b"), OwnerId: aws.String(testAccountId), AvailabilityZone: aws.String("us-east-1b"), AvailabilityZoneId: aws.String("use1-az6"), CidrBlock: aws.String("10.0.1.0/24")},
+			},
+		}, nil
+	}
+	mockSTSClient := &MockSTSClient{
+		mockGetCallerIdentity: func(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+			return &sts.GetCallerIdentityOutput{Account: aws.String(testAccountId)}, nil
+		},
+	}
+
+	input := AZCheckInput{InstanceTypes: []string{"c7g.xlarge"}, Subnets: []string{"subnet-a", "subnet-b"}, MinimumAZs: 1}
+	got, err := getTypeAvailabilityZones(context.Background(), input, mockEC2Client, &MockOutpostsClient{}, mockSTSClient)
+	if err != nil {
+		t.Fatalf("getTypeAvailabilityZones() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.AvailableZones, []string{"us-east-1b"}) || !reflect.DeepEqual(got.AvailableSubnets, []string{"subnet-b"}) {
+		t.Errorf("getTypeAvailabilityZones() zones = %v, subnets = %v, want [us-east-1b] and [subnet-b]", got.AvailableZones, got.AvailableSubnets)
+	}
+}
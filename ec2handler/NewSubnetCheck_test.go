@@ -0,0 +1,55 @@
+package ec2handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNewSubnetCheck(t *testing.T) {
+	mockEC2Client := newRegionMock(nil, nil)
+
+	tests := []struct {
+		name          string
+		subnets       []string
+		wantZones     []string
+		wantAvailable []string
+		wantAZs       []string
+		wantErr       bool
+	}{
+		{
+			name:          "Each zone once",
+			subnets:       []string{"subnet-e", "subnet-a", "subnet-op", "subnet-b"},
+			wantZones:     []string{"us-east-1a", "us-east-1b", "us-east-1e"},
+			wantAvailable: []string{"subnet-a", "subnet-b", "subnet-op"},
+			wantAZs:       []string{"us-east-1a", "us-east-1b"},
+		},
+		{
+			name:    "Subnet not found",
+			subnets: []string{"subnet-a", "subnet-typo"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSubnetCheck(context.Background(), "db.t4g.small", tt.subnets, "us-east-1", mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSubnetCheck() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.PhysicalResourceId != "InstanceTypAZCheck-db.t4g.small-" {
+				t.Errorf("NewSubnetCheck() PhysicalResourceId = %v", got.PhysicalResourceId)
+			}
+			if !reflect.DeepEqual(got.Zones, tt.wantZones) {
+				t.Errorf("NewSubnetCheck() Zones = %v, want %v", got.Zones, tt.wantZones)
+			}
+			available, zones := got.Available(func(zone string) bool { return zone != "us-east-1e" })
+			if !reflect.DeepEqual(available, tt.wantAvailable) || !reflect.DeepEqual(zones, tt.wantAZs) {
+				t.Errorf("Available() = %v, %v, want %v, %v", available, zones, tt.wantAvailable, tt.wantAZs)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package ec2handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// offering - an InstanceTypeOffering for the tests
+func offering(instanceType string, zone string) types.InstanceTypeOffering {
+	return types.InstanceTypeOffering{
+		InstanceType: types.InstanceType(instanceType),
+		Location:     aws.String(zone),
+		LocationType: types.LocationTypeAvailabilityZone,
+	}
+}
+
+func TestSelectInstanceType(t *testing.T) {
+	tests := []struct {
+		name         string
+		ranked       []string
+		offerings    []types.InstanceTypeOffering
+		wantSelected string
+		wantMatched  []string
+		wantByAZ     map[string][]string
+	}{
+		{
+			name:   "Earlier type wins a tie",
+			ranked: []string{"c7g.large", "c7g.xlarge"},
+			offerings: []types.InstanceTypeOffering{
+				offering("c7g.xlarge", "us-east-1a"),
+				offering("c7g.large", "us-east-1a"),
+			},
+			wantSelected: "c7g.large",
+			wantMatched:  []string{"c7g.large", "c7g.xlarge"},
+			wantByAZ: map[string][]string{
+				"us-east-1a": {"c7g.large", "c7g.xlarge"},
+			},
+		},
+		{
+			name:   "More zones beats preference",
+			ranked: []string{"t4g.small", "t3.small", "t3a.small"},
+			offerings: []types.InstanceTypeOffering{
+				offering("t4g.small", "us-east-1a"),
+				offering("t3.small", "us-east-1a"),
+				offering("t3.small", "us-east-1e"),
+			},
+			wantSelected: "t3.small",
+			wantMatched:  []string{"t4g.small", "t3.small"},
+			wantByAZ: map[string][]string{
+				"us-east-1a": {"t4g.small", "t3.small"},
+				"us-east-1e": {"t3.small"},
+			},
+		},
+		{
+			name:         "Nothing offered",
+			ranked:       []string{"t4g.small"},
+			wantSelected: "",
+			wantMatched:  []string{},
+			wantByAZ:     map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSelected, gotMatched, gotByAZ := SelectInstanceType(tt.ranked, tt.offerings)
+			if gotSelected != tt.wantSelected {
+				t.Errorf("SelectInstanceType() gotSelected = %v, want %v", gotSelected, tt.wantSelected)
+			}
+			if !reflect.DeepEqual(gotMatched, tt.wantMatched) {
+				t.Errorf("SelectInstanceType() gotMatched = %v, want %v", gotMatched, tt.wantMatched)
+			}
+			if !reflect.DeepEqual(gotByAZ, tt.wantByAZ) {
+				t.Errorf("SelectInstanceType() gotByAZ = %v, want %v", gotByAZ, tt.wantByAZ)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package ec2handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestValidateInstanceTypes(t *testing.T) {
+	catalogue := []types.InstanceTypeInfo{
+		instanceTypeInfo("t4g.small", 2, 2048),
+		instanceTypeInfo("t4g.micro", 2, 1024),
+		instanceTypeInfo("t3.small", 2, 2048),
+		instanceTypeInfo("m7g.large", 2, 8192),
+	}
+
+	tests := []struct {
+		name          string
+		instanceTypes []string
+		found         []types.InstanceTypeInfo
+		wantErr       string
+	}{
+		{
+			name:          "Known type",
+			instanceTypes: []string{"t4g.small"},
+			found:         catalogue[:1],
+		},
+		{
+			name:          "Patterns are not validated",
+			instanceTypes: []string{"x9z.*"},
+		},
+		{
+			name:          "Typo",
+			instanceTypes: []string{"t4g.small", "t4g.smal"},
+			found:         catalogue[:1],
+			wantErr:       "unknown instance type t4g.smal; did you mean t4g.small?",
+		},
+		{
+			name:          "Nothing close",
+			instanceTypes: []string{"p5.48xlarge"},
+			wantErr:       "unknown instance type p5.48xlarge",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInstanceTypes(context.Background(), tt.instanceTypes, tt.found, newRegionMock(catalogue, nil))
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("ValidateInstanceTypes() error = %v, want %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"t4g.smal", "t4g.small", 1},
+		{"t4g.small", "t4g.small", 0},
+		{"m5.larg", "m5.xlarge", 2},
+		{"", "t3.nano", 7},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
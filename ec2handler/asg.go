@@ -0,0 +1,284 @@
+package ec2handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	astypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// AutoScalingClient is an interface that defines the methods used from the autoscaling.Client.
+type AutoScalingClient interface {
+	DescribeAutoScalingGroups(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+}
+
+// OverrideAnalysis - the offerings of a mixed instances policy's overrides in the zones of the group's subnets
+type OverrideAnalysis struct {
+	AutoScalingGroupName string `json:"AutoScalingGroupName,omitempty"`
+	// AvailabilityMatrix has a row per override, in override order, and a column per zone of the subnets
+	AvailabilityMatrix
+	// SubnetsByAZ are the group's subnets in each zone
+	SubnetsByAZ map[string][]string `json:"SubnetsByAZ"`
+	// ZonesWithoutOverrides are the zones none of the overrides are offered in. The group never launches there.
+	ZonesWithoutOverrides []string `json:"ZonesWithoutOverrides"`
+}
+
+// GetAutoScalingGroupOverrides - Get the override instance types and subnets of an Auto Scaling group with a mixed
+// instances policy. Attribute-based overrides are expanded to the instance types that meet their requirements.
+func GetAutoScalingGroupOverrides(ctx context.Context, name string, asg AutoScalingClient, svc EC2Client) (overrides []string, subnets []string, err error) {
+	log.Printf("GetAutoScalingGroupOverrides(%v)", name)
+	var result *autoscaling.DescribeAutoScalingGroupsOutput
+	result, err = asg.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{name},
+	})
+	if err != nil {
+		log.Printf("Error describing Auto Scaling groups: %v", err)
+		return
+	}
+	if len(result.AutoScalingGroups) == 0 {
+		err = fmt.Errorf("Auto Scaling group %v not found", name)
+		return
+	}
+	group := result.AutoScalingGroups[0]
+	if group.MixedInstancesPolicy == nil || group.MixedInstancesPolicy.LaunchTemplate == nil {
+		err = fmt.Errorf("Auto Scaling group %v has no mixed instances policy", name)
+		return
+	}
+
+	for _, subnet := range strings.Split(aws.ToString(group.VPCZoneIdentifier), ",") {
+		if subnet = strings.TrimSpace(subnet); subnet != "" {
+			subnets = append(subnets, subnet)
+		}
+	}
+	if len(subnets) == 0 {
+		err = fmt.Errorf("Auto Scaling group %v has no VPCZoneIdentifier subnets", name)
+		return
+	}
+
+	var architectures []types.ArchitectureType
+	seen := make(map[string]bool)
+	for _, override := range group.MixedInstancesPolicy.LaunchTemplate.Overrides {
+		instanceTypes := []string{aws.ToString(override.InstanceType)}
+		if override.InstanceRequirements != nil {
+			if architectures == nil {
+				architectures, err = groupArchitectures(ctx, group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification, svc)
+				if err != nil {
+					return
+				}
+			}
+			instanceTypes, err = overrideRequirementsInstanceTypes(ctx, *override.InstanceRequirements, architectures, svc)
+			if err != nil {
+				return
+			}
+		}
+		for _, instanceType := range instanceTypes {
+			if instanceType != "" && !seen[instanceType] {
+				seen[instanceType] = true
+				overrides = append(overrides, instanceType)
+			}
+		}
+	}
+	if len(overrides) == 0 {
+		err = fmt.Errorf("Auto Scaling group %v has no instance type overrides", name)
+		return
+	}
+	log.Printf("Auto Scaling group %v: overrides %v in %v", name, overrides, subnets)
+	return
+}
+
+// groupArchitectures - the architecture of the AMI in the group's launch template, which attribute-based overrides
+// are limited to. Both x86_64 and arm64 when the template is only named or has no AMI.
+func groupArchitectures(ctx context.Context, specification *astypes.LaunchTemplateSpecification, svc EC2Client) (architectures []types.ArchitectureType, err error) {
+	architectures = []types.ArchitectureType{types.ArchitectureTypeX8664, types.ArchitectureTypeArm64}
+	if specification == nil || aws.ToString(specification.LaunchTemplateId) == "" {
+		log.Printf("Launch template has no ID, not limiting requirements to the AMI's architecture")
+		return
+	}
+	template, err := GetLaunchTemplate(ctx, aws.ToString(specification.LaunchTemplateId), aws.ToString(specification.Version), svc)
+	if err != nil || template.ImageId == "" {
+		return
+	}
+	image, err := GetImage(ctx, template.ImageId, svc)
+	if err != nil {
+		return
+	}
+	architectures = []types.ArchitectureType{types.ArchitectureType(image.Architecture)}
+	return
+}
+
+// overrideRequirementsInstanceTypes - the instance types of the given architectures meeting an attribute-based
+// override
+func overrideRequirementsInstanceTypes(ctx context.Context, requirements astypes.InstanceRequirements, architectures []types.ArchitectureType, svc EC2Client) (instanceTypes []string, err error) {
+	// The Auto Scaling and EC2 requirement types have the same fields and enum values, so every requirement, including
+	// CPU manufacturers, allowed and excluded types and accelerators, is carried across by its JSON form
+	var request types.InstanceRequirementsRequest
+	data, err := json.Marshal(requirements)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &request); err != nil {
+		return
+	}
+	infos, err := getInstanceTypesFromRequest(ctx, &ec2.GetInstanceTypesFromInstanceRequirementsInput{
+		ArchitectureTypes:    architectures,
+		VirtualizationTypes:  []types.VirtualizationType{types.VirtualizationTypeHvm},
+		InstanceRequirements: &request,
+	}, svc)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		instanceTypes = append(instanceTypes, string(info.InstanceType))
+	}
+	return
+}
+
+// AnalyzeOverrides - Check each override instance type in the zones of the subnets, reporting the zones where none of
+// them are offered
+func AnalyzeOverrides(ctx context.Context, overrides []string, subnets []string, svc EC2Client) (analysis OverrideAnalysis, err error) {
+	log.Printf("AnalyzeOverrides(%v, %v)", overrides, subnets)
+	if len(overrides) == 0 || len(subnets) == 0 {
+		err = fmt.Errorf("overrides and subnets are required")
+		return
+	}
+
+	subnetDetails, err := GetSubnets(ctx, subnets, svc)
+	if err != nil {
+		return
+	}
+	if err = CheckSubnetsFound(subnets, subnetDetails, ""); err != nil {
+		return
+	}
+	analysis.SubnetsByAZ = make(map[string][]string)
+	zoneIds := make(map[string]string)
+	for _, subnet := range subnetDetails {
+		zone := aws.ToString(subnet.AvailabilityZone)
+		analysis.SubnetsByAZ[zone] = append(analysis.SubnetsByAZ[zone], aws.ToString(subnet.SubnetId))
+		zoneIds[zone] = aws.ToString(subnet.AvailabilityZoneId)
+	}
+	zones := make([]string, 0, len(zoneIds))
+	for zone := range zoneIds {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	for _, zone := range zones {
+		analysis.Zones = append(analysis.Zones, MatrixZone{ZoneName: zone, ZoneId: zoneIds[zone]})
+	}
+
+	offerings, err := GetInstanceTypeOfferings(ctx, overrides, zones, svc)
+	if err != nil {
+		return
+	}
+	_, _, byAZ := SelectInstanceType(overrides, offerings)
+
+	for _, override := range overrides {
+		row := MatrixRow{
+			InstanceType:   override,
+			Offered:        make(map[string]bool, len(zones)),
+			MissingFromAZs: []string{},
+		}
+		for _, zone := range zones {
+			for _, offered := range byAZ[zone] {
+				if offered == override {
+					row.Offered[zone] = true
+				}
+			}
+			if !row.Offered[zone] {
+				row.Offered[zone] = false
+				row.MissingFromAZs = append(row.MissingFromAZs, zone)
+			}
+		}
+		analysis.InstanceTypes = append(analysis.InstanceTypes, row)
+	}
+
+	analysis.ZonesWithoutOverrides = []string{}
+	for _, zone := range zones {
+		if len(byAZ[zone]) == 0 {
+			analysis.ZonesWithoutOverrides = append(analysis.ZonesWithoutOverrides, zone)
+		}
+	}
+	log.Printf("Zones without an override: %v", analysis.ZonesWithoutOverrides)
+	return
+}
+
+// FormatOverrideAnalysis - Render the analysis as FormatMatrix does, followed by the zones without an override. JSON
+// includes the subnets by zone.
+func FormatOverrideAnalysis(analysis OverrideAnalysis, format string) (string, error) {
+	if strings.ToLower(format) == MatrixFormatJSON {
+		out, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	}
+	out, err := FormatMatrix(analysis.AvailabilityMatrix, format)
+	if err != nil {
+		return "", err
+	}
+	if len(analysis.ZonesWithoutOverrides) == 0 || strings.ToLower(format) == MatrixFormatCSV {
+		return out, nil
+	}
+	var sb strings.Builder
+	sb.WriteString(out)
+	sb.WriteString("\n")
+	for _, zone := range analysis.ZonesWithoutOverrides {
+		fmt.Fprintf(&sb, "WARNING: no override is offered in %s (%s), the group never launches there\n",
+			zone, strings.Join(analysis.SubnetsByAZ[zone], ", "))
+	}
+	return sb.String(), nil
+}
+
+// LaunchTemplateOverride - a mixed instances policy override, shaped for CloudFormation
+type LaunchTemplateOverride struct {
+	InstanceType     string `json:"InstanceType"`
+	WeightedCapacity string `json:"WeightedCapacity"`
+}
+
+// GetLaunchTemplateOverrides - The candidate instance types (in order) that are offered in every one of the zones, as
+// overrides weighted by their vCPUs relative to the smallest of them
+func GetLaunchTemplateOverrides(candidates []types.InstanceTypeInfo, byAZ map[string][]string, zones []string) []LaunchTemplateOverride {
+	overrides := []LaunchTemplateOverride{}
+	if len(zones) == 0 {
+		return overrides
+	}
+	offeredIn := make(map[string]int)
+	for _, zone := range zones {
+		for _, instanceType := range byAZ[zone] {
+			offeredIn[instanceType]++
+		}
+	}
+
+	var kept []types.InstanceTypeInfo
+	smallest := int32(0)
+	for _, candidate := range candidates {
+		instanceType := string(candidate.InstanceType)
+		if offeredIn[instanceType] != len(zones) {
+			continue
+		}
+		offeredIn[instanceType] = 0 // only once
+		kept = append(kept, candidate)
+		if vcpus := defaultVCpus(candidate); vcpus > 0 && (smallest == 0 || vcpus < smallest) {
+			smallest = vcpus
+		}
+	}
+	for _, candidate := range kept {
+		weight := int32(1)
+		if smallest > 0 {
+			weight = max(defaultVCpus(candidate)/smallest, 1)
+		}
+		overrides = append(overrides, LaunchTemplateOverride{
+			InstanceType:     string(candidate.InstanceType),
+			WeightedCapacity: strconv.Itoa(int(weight)),
+		})
+	}
+	return overrides
+}
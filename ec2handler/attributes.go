@@ -0,0 +1,56 @@
+package ec2handler
+
+import (
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// InstanceTypeAttributes - the instance type details templates use to size things like JVM heap or ENI counts
+type InstanceTypeAttributes struct {
+	Architecture   string
+	VCpus          int32
+	MemoryMiB      int64
+	MaxENIs        int32 // on the default network card
+	IPv4PerENI     int32
+	EbsOptimized   bool
+	HypervisorType string // nitro or xen, empty for bare metal
+}
+
+// GetInstanceTypeAttributes - Get the attributes from the DescribeInstanceTypes details of the instance type
+func GetInstanceTypeAttributes(info types.InstanceTypeInfo) (attributes InstanceTypeAttributes) {
+	attributes.Architecture = primaryArchitecture(info)
+	attributes.VCpus = defaultVCpus(info)
+	attributes.MemoryMiB = memoryMiB(info)
+	attributes.HypervisorType = string(info.Hypervisor)
+	if info.EbsInfo != nil {
+		attributes.EbsOptimized = info.EbsInfo.EbsOptimizedSupport != types.EbsOptimizedSupportUnsupported
+	}
+	if network := info.NetworkInfo; network != nil {
+		attributes.IPv4PerENI = aws.ToInt32(network.Ipv4AddressesPerInterface)
+		attributes.MaxENIs = aws.ToInt32(network.MaximumNetworkInterfaces)
+		// With several network cards the limit that applies by default is the default card's
+		for _, card := range network.NetworkCards {
+			if aws.ToInt32(card.NetworkCardIndex) == aws.ToInt32(network.DefaultNetworkCardIndex) {
+				attributes.MaxENIs = aws.ToInt32(card.MaximumNetworkInterfaces)
+				break
+			}
+		}
+	}
+	return
+}
+
+// primaryArchitecture - the architecture an AMI for the instance type would normally be built for
+func primaryArchitecture(info types.InstanceTypeInfo) string {
+	if info.ProcessorInfo == nil || len(info.ProcessorInfo.SupportedArchitectures) == 0 {
+		return ""
+	}
+	architectures := info.ProcessorInfo.SupportedArchitectures
+	for _, preferred := range []types.ArchitectureType{types.ArchitectureTypeArm64, types.ArchitectureTypeX8664} {
+		if slices.Contains(architectures, preferred) {
+			return string(preferred)
+		}
+	}
+	return string(architectures[0])
+}
@@ -0,0 +1,109 @@
+package ec2handler
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// CapacityReservation - an On-Demand Capacity Reservation with free capacity in a zone
+type CapacityReservation struct {
+	CapacityReservationId  string
+	AvailableInstanceCount int32
+	// OwnerId is the account that owns the reservation, which differs from the caller's for shared reservations
+	OwnerId string
+}
+
+// GetCapacityReservations - Get the active capacity reservations for the instance type that have free capacity,
+// keeping the one with the most free capacity in each zone. Reservations shared with the account are included. When
+// a group ARN is given only the reservations in that capacity reservation group count. The zones (name to zone ID)
+// are matched by zone ID, and the reservations are returned by zone name.
+func GetCapacityReservations(ctx context.Context, instanceType string, zoneIds map[string]string, groupArn string, svc EC2Client) (reservations map[string]CapacityReservation, err error) {
+	log.Printf("GetCapacityReservations(%v, %v, %v)", instanceType, zoneIds, groupArn)
+	reservations = make(map[string]CapacityReservation)
+	names := zoneNames(zoneIds)
+	input := &ec2.DescribeCapacityReservationsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("instance-type"),
+				Values: []string{instanceType},
+			},
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(types.CapacityReservationStateActive)},
+			},
+		},
+	}
+	for {
+		var result *ec2.DescribeCapacityReservationsOutput
+		result, err = svc.DescribeCapacityReservations(ctx, input)
+		if err != nil {
+			log.Printf("Error describing capacity reservations: %v", err)
+			return
+		}
+		for _, reservation := range result.CapacityReservations {
+			available := aws.ToInt32(reservation.AvailableInstanceCount)
+			zone, ok := names[aws.ToString(reservation.AvailabilityZoneId)]
+			if !ok || available <= 0 || available <= reservations[zone].AvailableInstanceCount {
+				continue
+			}
+			if groupArn != "" {
+				var inGroup bool
+				inGroup, err = inCapacityReservationGroup(ctx, aws.ToString(reservation.CapacityReservationId), groupArn, svc)
+				if err != nil {
+					return
+				}
+				if !inGroup {
+					continue
+				}
+			}
+			reservations[zone] = CapacityReservation{
+				CapacityReservationId:  aws.ToString(reservation.CapacityReservationId),
+				AvailableInstanceCount: available,
+				OwnerId:                aws.ToString(reservation.OwnerId),
+			}
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	log.Printf("Capacity reservations: %v", reservations)
+	return
+}
+
+// inCapacityReservationGroup - Check whether the capacity reservation is in the capacity reservation group
+func inCapacityReservationGroup(ctx context.Context, capacityReservationId string, groupArn string, svc EC2Client) (bool, error) {
+	input := &ec2.GetGroupsForCapacityReservationInput{
+		CapacityReservationId: aws.String(capacityReservationId),
+	}
+	for {
+		result, err := svc.GetGroupsForCapacityReservation(ctx, input)
+		if err != nil {
+			log.Printf("Error getting groups for capacity reservation %v: %v", capacityReservationId, err)
+			return false, err
+		}
+		for _, group := range result.CapacityReservationGroups {
+			if aws.ToString(group.GroupArn) == groupArn {
+				return true, nil
+			}
+		}
+		if result.NextToken == nil {
+			return false, nil
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+// PreferCapacityReservations - Move the zones with a capacity reservation to the front, keeping their order otherwise
+func PreferCapacityReservations(zones []string, reservations map[string]CapacityReservation) {
+	sort.SliceStable(zones, func(i, j int) bool {
+		_, a := reservations[zones[i]]
+		_, b := reservations[zones[j]]
+		return a && !b
+	})
+}
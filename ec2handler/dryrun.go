@@ -0,0 +1,139 @@
+package ec2handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// dryRunOperation - the error code EC2 returns when a DryRun request would have succeeded
+const dryRunOperation = "DryRunOperation"
+
+// subnetRejectionCodes are the prefixes of the error codes that reject a launch in a particular subnet. Other errors
+// (throttling, authorization, server errors) aren't about the subnet, so they fail the check.
+var subnetRejectionCodes = []string{
+	"InsufficientInstanceCapacity",
+	"Unsupported",
+	"InvalidParameterCombination",
+	"VcpuLimitExceeded",
+	"InvalidSubnet",
+	"InvalidGroup",
+	"InvalidAMIID",
+}
+
+// isSubnetRejection - true if the error code rejects the launch in the subnet, rather than failing the check
+func isSubnetRejection(code string) bool {
+	return slices.ContainsFunc(subnetRejectionCodes, func(prefix string) bool { return strings.HasPrefix(code, prefix) })
+}
+
+// LaunchSpec - what the instances are launched with, for the DryRun launch check
+type LaunchSpec struct {
+	ImageId          string
+	KeyName          string
+	SecurityGroupIds []string
+	// IamInstanceProfile is the name or ARN of the instance profile
+	IamInstanceProfile string
+	// LaunchTemplateId and LaunchTemplateVersion launch from a template, the other settings overriding it
+	LaunchTemplateId      string
+	LaunchTemplateVersion string
+	// NetworkInterfaces gives the subnet and security groups on the primary network interface, for templates that
+	// define one (EC2 rejects an instance level subnet with them)
+	NetworkInterfaces bool
+}
+
+// request - the RunInstances input to DryRun the launch of the instance type in the subnet
+func (l LaunchSpec) request(instanceType string, subnetId string) *ec2.RunInstancesInput {
+	input := &ec2.RunInstancesInput{
+		DryRun:       aws.Bool(true),
+		InstanceType: types.InstanceType(instanceType),
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+		SubnetId:     aws.String(subnetId),
+	}
+	if l.LaunchTemplateId != "" {
+		input.LaunchTemplate = &types.LaunchTemplateSpecification{
+			LaunchTemplateId: aws.String(l.LaunchTemplateId),
+			Version:          aws.String(l.LaunchTemplateVersion),
+		}
+	}
+	if l.ImageId != "" {
+		input.ImageId = aws.String(l.ImageId)
+	}
+	if l.KeyName != "" {
+		input.KeyName = aws.String(l.KeyName)
+	}
+	if len(l.SecurityGroupIds) > 0 {
+		input.SecurityGroupIds = l.SecurityGroupIds
+	}
+	if strings.HasPrefix(l.IamInstanceProfile, "arn:") {
+		input.IamInstanceProfile = &types.IamInstanceProfileSpecification{Arn: aws.String(l.IamInstanceProfile)}
+	} else if l.IamInstanceProfile != "" {
+		input.IamInstanceProfile = &types.IamInstanceProfileSpecification{Name: aws.String(l.IamInstanceProfile)}
+	}
+	if l.NetworkInterfaces {
+		input.NetworkInterfaces = []types.InstanceNetworkInterfaceSpecification{
+			{
+				DeviceIndex: aws.Int32(0),
+				SubnetId:    input.SubnetId,
+				Groups:      input.SecurityGroupIds,
+			},
+		}
+		input.SubnetId, input.SecurityGroupIds = nil, nil
+	}
+	return input
+}
+
+// DryRunLaunch - DryRun RunInstances for the instance type in each subnet, returning the subnets EC2 would launch in
+// and why it rejected the others. Capacity, support, vCPU limit and invalid subnet, group or AMI errors are rejections;
+// any other error fails the check.
+func DryRunLaunch(ctx context.Context, instanceType string, subnets []string, spec LaunchSpec, svc EC2Client) (passed []string, failures map[string]string, err error) {
+	log.Printf("DryRunLaunch(%v, %v, %+v)", instanceType, subnets, spec)
+	failures = make(map[string]string)
+	for _, subnet := range subnets {
+		_, runErr := svc.RunInstances(ctx, spec.request(instanceType, subnet))
+		var apiErr smithy.APIError
+		switch {
+		case runErr == nil:
+			// Only happens if DryRun was ignored, which would have launched an instance
+			err = fmt.Errorf("RunInstances DryRun in %v launched an instance", subnet)
+			return
+		case !errors.As(runErr, &apiErr):
+			log.Printf("Error running instances in %v: %v", subnet, runErr)
+			err = runErr
+			return
+		case apiErr.ErrorCode() == dryRunOperation:
+			passed = append(passed, subnet)
+		case !isSubnetRejection(apiErr.ErrorCode()):
+			log.Printf("Error running instances in %v: %v", subnet, runErr)
+			err = runErr
+			return
+		default:
+			log.Printf("DryRun in %v failed: %v", subnet, runErr)
+			failures[subnet] = fmt.Sprintf("%s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+		}
+	}
+	return
+}
+
+// dryRunFailuresError - the error when the launch failed in every subnet
+func dryRunFailuresError(instanceType string, failures map[string]string) error {
+	subnets := make([]string, 0, len(failures))
+	for subnet := range failures {
+		subnets = append(subnets, subnet)
+	}
+	sort.Strings(subnets)
+	reasons := make([]string, len(subnets))
+	for i, subnet := range subnets {
+		reasons[i] = fmt.Sprintf("%s (%s)", subnet, failures[subnet])
+	}
+	return fmt.Errorf("%v can't be launched in any of the subnets: %s", instanceType, strings.Join(reasons, "; "))
+}
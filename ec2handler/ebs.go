@@ -0,0 +1,83 @@
+package ec2handler
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// EbsVolume - the EBS volume the instance type must be able to drive
+type EbsVolume struct {
+	VolumeType string
+	// Iops and Throughput (MiB/s) are the provisioned performance, 0 when not provisioned
+	Iops       int32
+	Throughput int32
+}
+
+// ebsVolumeProvisioning - whether a volume type can be provisioned with IOPS and throughput
+type ebsVolumeProvisioning struct {
+	iops       bool
+	throughput bool
+}
+
+// ebsVolumeTypes are the EBS volume types and what they can be provisioned with. The most a volume can be provisioned
+// with keeps going up (gp3 went from 16000 to 80000 IOPS), so that is left to EC2 and the instance type's limits.
+var ebsVolumeTypes = map[string]ebsVolumeProvisioning{
+	"gp2":      {},
+	"gp3":      {iops: true, throughput: true},
+	"io1":      {iops: true},
+	"io2":      {iops: true},
+	"st1":      {},
+	"sc1":      {},
+	"standard": {},
+}
+
+// Validate - Check the volume type exists and can be provisioned with IOPS and throughput, if they are given
+func (v EbsVolume) Validate() error {
+	if v.VolumeType == "" {
+		return fmt.Errorf("VolumeType is required with Iops and Throughput")
+	}
+	provisioning, ok := ebsVolumeTypes[v.VolumeType]
+	if !ok {
+		return fmt.Errorf("unknown EBS volume type %q (use gp2, gp3, io1, io2, st1, sc1 or standard)", v.VolumeType)
+	}
+	if v.Iops > 0 && !provisioning.iops {
+		return fmt.Errorf("IOPS can't be provisioned for %s volumes", v.VolumeType)
+	}
+	if v.Throughput > 0 && !provisioning.throughput {
+		return fmt.Errorf("throughput can't be provisioned for %s volumes", v.VolumeType)
+	}
+	return nil
+}
+
+// CheckEbsCompatibility - Check the instance type can attach the volume and drive its IOPS and throughput, returning
+// the reason if it can't. EbsInfo is the same in every zone and EC2 has no per zone EBS capabilities, so this filters
+// instance types; the zones follow from where the types that pass are offered.
+func CheckEbsCompatibility(volume EbsVolume, info types.InstanceTypeInfo) error {
+	if info.EbsInfo == nil {
+		return fmt.Errorf("%s does not report EBS support", info.InstanceType)
+	}
+
+	// io2 volumes are all Block Express now, which needs a Nitro instance with NVMe EBS volumes
+	if volume.VolumeType == "io2" {
+		if info.Hypervisor != types.InstanceTypeHypervisorNitro && !aws.ToBool(info.BareMetal) {
+			return fmt.Errorf("io2 Block Express volumes need a Nitro instance but %s is %s", info.InstanceType, info.Hypervisor)
+		}
+		if info.EbsInfo.NvmeSupport == types.EbsNvmeSupportUnsupported {
+			return fmt.Errorf("io2 Block Express volumes need NVMe but %s does not support it", info.InstanceType)
+		}
+	}
+
+	optimized := info.EbsInfo.EbsOptimizedInfo
+	if volume.Iops > 0 && optimized != nil && volume.Iops > aws.ToInt32(optimized.MaximumIops) {
+		return fmt.Errorf("%s can drive at most %d IOPS, not %d", info.InstanceType, aws.ToInt32(optimized.MaximumIops), volume.Iops)
+	}
+	if volume.Throughput > 0 && optimized != nil && float64(volume.Throughput) > aws.ToFloat64(optimized.MaximumThroughputInMBps) {
+		return fmt.Errorf("%s can drive at most %v MB/s, not %d", info.InstanceType, aws.ToFloat64(optimized.MaximumThroughputInMBps), volume.Throughput)
+	}
+	if (volume.Iops > 0 || volume.Throughput > 0) && optimized == nil {
+		return fmt.Errorf("%s is not EBS optimized, so provisioned performance can't be relied on", info.InstanceType)
+	}
+	return nil
+}
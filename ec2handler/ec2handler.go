@@ -2,8 +2,11 @@ package ec2handler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 
 	"net"
 
@@ -11,92 +14,703 @@ import (
 	"github.com/aws/aws-lambda-go/lambdacontext"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/outposts"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 // EC2Client is an interface that defines the methods used from the ec2.Client.
 type EC2Client interface {
 	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeAvailabilityZones(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error)
+	DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error)
+	DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error)
+	DescribeNetworkInterfaces(ctx context.Context, params *ec2.DescribeNetworkInterfacesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeNetworkInterfacesOutput, error)
+	GetInstanceTypesFromInstanceRequirements(ctx context.Context, params *ec2.GetInstanceTypesFromInstanceRequirementsInput, optFns ...func(*ec2.Options)) (*ec2.GetInstanceTypesFromInstanceRequirementsOutput, error)
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+	DescribeSpotPriceHistory(ctx context.Context, params *ec2.DescribeSpotPriceHistoryInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error)
+	GetSpotPlacementScores(ctx context.Context, params *ec2.GetSpotPlacementScoresInput, optFns ...func(*ec2.Options)) (*ec2.GetSpotPlacementScoresOutput, error)
+	DescribeCapacityReservations(ctx context.Context, params *ec2.DescribeCapacityReservationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error)
+	RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+	DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	GetGroupsForCapacityReservation(ctx context.Context, params *ec2.GetGroupsForCapacityReservationInput, optFns ...func(*ec2.Options)) (*ec2.GetGroupsForCapacityReservationOutput, error)
+	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+	DescribeHosts(ctx context.Context, params *ec2.DescribeHostsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeHostsOutput, error)
+	CreateNetworkInterface(ctx context.Context, params *ec2.CreateNetworkInterfaceInput, optFns ...func(*ec2.Options)) (*ec2.CreateNetworkInterfaceOutput, error)
 }
 
-// GetTypeAvailabilityZones - Get the availability zones for a given instance type and subnets
-func GetTypeAvailabilityZones(ctx context.Context, instanceType string, subnets []string) (physicalResourceId string, availableZones []string, availableSubnets []string, firstSubnetId string, firstAZ string, nextIP string, err error) {
-	log.Printf("GetTypeAvailabilityZones(%#v, %v, %v)", ctx, instanceType, subnets)
-	lc, _ := lambdacontext.FromContext(ctx)
-	physicalResourceId = fmt.Sprintf("InstanceTypAZCheck-%v-%v", instanceType, lc.AwsRequestID)
+// ModeEC2 - the custom resource Mode for the EC2 check, which is also the default
+const ModeEC2 = "EC2"
+
+// AZCheckInput - the parameters of an availability zone check
+type AZCheckInput struct {
+	// InstanceTypes are exact instance types or glob patterns (e.g. c7g.*large), in order of preference
+	InstanceTypes []string
+	// Requirements select instance types by attributes, ranked smallest first after any InstanceTypes
+	Requirements *InstanceRequirements
+	// Subnets are the subnets the instance could be built in
+	Subnets []string
+	// ZoneIds, when set, limits the subnets to those in these zones (e.g. where a paired service is also available).
+	// They are zone IDs rather than names, as the names can be of another account's mapping.
+	ZoneIds []string
+	// ImageId is an optional AMI the instance types must be able to boot
+	ImageId string
+	// MinimumAZs is the number of zones the type must be offered in, 0 for all the zones of the subnets. When the
+	// selected type falls short, equivalent types are suggested.
+	MinimumAZs int
+	// SpotTargetCapacity, when set, orders the zones by their spot placement score for that many instances
+	SpotTargetCapacity int
+	// IncludeSpotPrice looks up the latest spot price of the selected type in each zone
+	IncludeSpotPrice bool
+	// SpotProductDescription is the product the spot prices are for, defaulting to Linux/UNIX
+	SpotProductDescription string
+	// SelectionStrategy orders the zones: default, spot-placement-score or lowest-spot-price
+	SelectionStrategy string
+	// CheckCapacityReservations puts the zones with an active capacity reservation with free capacity first
+	CheckCapacityReservations bool
+	// RequireCapacityReservation keeps only the zones with a capacity reservation with free capacity
+	RequireCapacityReservation bool
+	// CapacityReservationGroupArn limits the reservations to those in the capacity reservation group
+	CapacityReservationGroupArn string
+	// DesiredCount, when set, checks the instances fit in the account's On-Demand vCPU quota for the selected type
+	DesiredCount int
+	// QuotaWarnOnly reports a quota shortfall as a warning rather than failing
+	QuotaWarnOnly bool
+	// DryRunLaunch keeps only the subnets where a RunInstances DryRun of the selected type succeeds, using ImageId and
+	// the rest of the launch settings below
+	DryRunLaunch bool
+	// KeyName, SecurityGroupIds and IamInstanceProfile (name or ARN) are used by the DryRun launch
+	KeyName            string
+	SecurityGroupIds   []string
+	IamInstanceProfile string
+	// LaunchTemplateId checks a launch template version (LaunchTemplateVersion, default $Default). The instance
+	// type(s), image and launch settings come from the template unless they are given.
+	LaunchTemplateId      string
+	LaunchTemplateVersion string
+	// VolumeType, Iops and Throughput (MiB/s) describe an EBS volume the instance types must be able to attach and
+	// drive, e.g. io2 with 100000 IOPS
+	VolumeType string
+	Iops       int32
+	Throughput int32
+	// PlacementGroupStrategy (cluster, partition or spread) keeps the types that can be launched in such a placement
+	// group. A cluster placement group is in a single zone, so only the first zone is returned.
+	PlacementGroupStrategy string
+	// RequireEFA keeps the types with an Elastic Fabric Adapter
+	RequireEFA bool
+	// Tenancy is default, dedicated or host. A VPC with dedicated tenancy makes it dedicated, and host only returns
+	// the zones with an available Dedicated Host for the selected type.
+	Tenancy string
+	// Region is the region the subnets are in and the checks are made in, defaulting to the configured region
+	Region string
+	// RoleArn is a role in the account the subnets are in, assumed (with ExternalId, if any) for the checks
+	RoleArn    string
+	ExternalId string
+}
+
+// AZCheckResult - the outcome of an availability zone check
+type AZCheckResult struct {
+	PhysicalResourceId string
+	// AvailableZones and AvailableSubnets are where the SelectedInstanceType is offered
+	AvailableZones   []string
+	AvailableSubnets []string
+	FirstSubnetId    string
+	FirstAZ          string
+	NextIP           string
+	// SelectedInstanceType is the matched type offered in the most zones, ties going to the more preferred type
+	SelectedInstanceType string
+	// MatchedInstanceTypes are the concrete types matched by the patterns that are offered in at least one zone
+	MatchedInstanceTypes []string
+	// InstanceTypesByAZ are the matched types offered in each zone, in order of preference
+	InstanceTypesByAZ map[string][]string
+	// Attributes are the details of the SelectedInstanceType
+	Attributes InstanceTypeAttributes
+	// EquivalentInstanceTypes are similar types offered in at least MinimumAZs zones, when the selected type isn't
+	EquivalentInstanceTypes []string
+	// SpotPlacementScores are the scores (1-10) of the zones, when SpotTargetCapacity was given
+	SpotPlacementScores map[string]int32
+	// SpotPrices are the latest spot prices (USD per hour) of the selected type, when requested
+	SpotPrices map[string]string
+	// CapacityReservations are the reservations with the most free capacity in each zone, when checked
+	CapacityReservations map[string]CapacityReservation
+	// VCpuQuota is the On-Demand vCPU quota of the selected type, when DesiredCount was given
+	VCpuQuota VCpuQuota
+	// DryRunFailures are the subnets EC2 rejected the DryRun launch in, with the error code and message
+	DryRunFailures map[string]string
+	// LaunchTemplateVersion is the version number of the launch template that was checked
+	LaunchTemplateVersion string
+	// LaunchTemplateOverrides are the matched types offered in every one of the AvailableZones, weighted by vCPUs
+	LaunchTemplateOverrides []LaunchTemplateOverride
+	// Tenancy is the tenancy the instance types were checked for, from the input or the subnets' VPC
+	Tenancy string
+	// Hosts are the available Dedicated Hosts with capacity for the selected type in each zone, for host tenancy
+	Hosts map[string][]string
+	// SubnetZones are the zone type and parent zone of each of the AvailableSubnets
+	SubnetZones map[string]SubnetZone
+	// AccountId is the account the check was made from
+	AccountId string
+	// SubnetOwners are the accounts that own each of the AvailableSubnets, another account for a shared VPC
+	SubnetOwners map[string]string
+	// SharedSubnetFailures are the shared subnets the account can't create network interfaces in, with the reason
+	SharedSubnetFailures map[string]string
+}
+
+// Data - the custom resource attributes for the result
+func (r AZCheckResult) Data() (data map[string]interface{}, err error) {
+	byAZ, err := json.Marshal(r.InstanceTypesByAZ)
+	if err != nil {
+		return
+	}
+	scores, err := jsonObject(r.SpotPlacementScores)
+	if err != nil {
+		return
+	}
+	prices, err := jsonObject(r.SpotPrices)
+	if err != nil {
+		return
+	}
+	reservations, err := jsonObject(r.CapacityReservations)
+	if err != nil {
+		return
+	}
+	dryRunFailures, err := jsonObject(r.DryRunFailures)
+	if err != nil {
+		return
+	}
+	overrides, err := json.Marshal(r.LaunchTemplateOverrides)
+	if err != nil {
+		return
+	}
+	hosts, err := jsonObject(r.Hosts)
+	if err != nil {
+		return
+	}
+	subnetZones, err := jsonObject(r.SubnetZones)
+	if err != nil {
+		return
+	}
+	owners, err := jsonObject(r.SubnetOwners)
+	if err != nil {
+		return
+	}
+	sharedSubnetFailures, err := jsonObject(r.SharedSubnetFailures)
+	if err != nil {
+		return
+	}
+	var firstHost string
+	if ids := r.Hosts[r.FirstAZ]; len(ids) > 0 {
+		firstHost = ids[0]
+	}
+	firstReservation := r.CapacityReservations[r.FirstAZ]
+	data = map[string]interface{}{
+		"AvailableInAZs":                    r.AvailableZones,
+		"AvailableInSubnetIds":              r.AvailableSubnets,
+		"SubnetId":                          r.FirstSubnetId,
+		"AZ":                                r.FirstAZ,
+		"PrivateIP":                         r.NextIP,
+		"SelectedInstanceType":              r.SelectedInstanceType,
+		"MatchedInstanceTypes":              r.MatchedInstanceTypes,
+		"InstanceTypesByAZ":                 string(byAZ),
+		"Architecture":                      r.Attributes.Architecture,
+		"VCpus":                             r.Attributes.VCpus,
+		"MemoryMiB":                         r.Attributes.MemoryMiB,
+		"MaxENIs":                           r.Attributes.MaxENIs,
+		"IPv4PerENI":                        r.Attributes.IPv4PerENI,
+		"EbsOptimized":                      r.Attributes.EbsOptimized,
+		"HypervisorType":                    r.Attributes.HypervisorType,
+		"EquivalentInstanceTypes":           r.EquivalentInstanceTypes,
+		"SpotPlacementScores":               scores,
+		"SpotPrices":                        prices,
+		"CapacityReservations":              reservations,
+		"CapacityReservationId":             firstReservation.CapacityReservationId,
+		"CapacityReservationAvailableCount": firstReservation.AvailableInstanceCount,
+		"VCpuQuota":                         r.VCpuQuota.Limit,
+		"VCpuQuotaUsage":                    r.VCpuQuota.Usage,
+		"VCpuRequired":                      r.VCpuQuota.Required,
+		"QuotaWarning":                      r.VCpuQuota.Warning,
+		"DryRunFailures":                    dryRunFailures,
+		"LaunchTemplateVersion":             r.LaunchTemplateVersion,
+		"LaunchTemplateOverrides":           string(overrides),
+		"Tenancy":                           r.Tenancy,
+		"HostIds":                           hosts,
+		"HostId":                            firstHost,
+		"SubnetZones":                       subnetZones,
+		"ZoneType":                          r.SubnetZones[r.FirstSubnetId].ZoneType,
+		"ParentZone":                        r.SubnetZones[r.FirstSubnetId].ParentZoneName,
+		"AccountId":                         r.AccountId,
+		"SubnetOwnerIds":                    owners,
+		"SubnetOwnerId":                     r.SubnetOwners[r.FirstSubnetId],
+		"SharedVpc":                         r.SubnetOwners[r.FirstSubnetId] != "" && r.SubnetOwners[r.FirstSubnetId] != r.AccountId,
+		"SharedSubnetFailures":              sharedSubnetFailures,
+	}
+	return
+}
+
+// jsonObject - a map as a JSON string attribute, {} when it is nil
+func jsonObject[V any](m map[string]V) (string, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	out, err := json.Marshal(m)
+	return string(out), err
+}
+
+// GetTypeAvailabilityZones - Get the availability zones for the given instance types and subnets
+func GetTypeAvailabilityZones(ctx context.Context, input AZCheckInput) (result AZCheckResult, err error) {
+	log.Printf("GetTypeAvailabilityZones(%#v, %v, %v)", ctx, input.InstanceTypes, input.Subnets)
 	var cfg aws.Config
-	cfg, err = config.LoadDefaultConfig(ctx)
+	cfg, err = LoadConfig(ctx, input.Region, input.RoleArn, input.ExternalId)
 	if err != nil {
-		log.Printf("Error loading AWS config: %v", err)
 		return
 	}
+	if input.Region == "" {
+		input.Region = cfg.Region
+	}
 
 	svc := ec2.NewFromConfig(cfg)
+	result, err = getTypeAvailabilityZones(ctx, input, svc, outposts.NewFromConfig(cfg), sts.NewFromConfig(cfg))
+	if err != nil || input.DesiredCount <= 0 || result.SelectedInstanceType == "" {
+		return
+	}
+	result.VCpuQuota, err = CheckVCpuQuota(ctx, result.SelectedInstanceType, result.Attributes.VCpus, input.DesiredCount,
+		input.QuotaWarnOnly, svc, servicequotas.NewFromConfig(cfg))
+	return
+}
 
-	var azMap map[string]string
-	azMap, err = GetSubnetDetails(subnets, svc)
+// getTypeAvailabilityZones - GetTypeAvailabilityZones using the given clients
+func getTypeAvailabilityZones(ctx context.Context, input AZCheckInput, svc EC2Client, outpostsSvc OutpostsClient, stsSvc STSClient) (result AZCheckResult, err error) {
+	// Take what wasn't given from the launch template
+	var template LaunchTemplate
+	if input.LaunchTemplateId != "" {
+		template, err = GetLaunchTemplate(ctx, input.LaunchTemplateId, input.LaunchTemplateVersion, svc)
+		if err != nil {
+			return
+		}
+		input = template.apply(input)
+		result.LaunchTemplateVersion = template.Version
+		if len(input.InstanceTypes) == 0 && input.Requirements == nil {
+			err = fmt.Errorf("launch template %v version %v has no instance type", template.LaunchTemplateId, template.Version)
+			return
+		}
+		if len(input.Subnets) == 0 {
+			err = fmt.Errorf("Subnets are required when launch template %v doesn't set a subnet", template.LaunchTemplateId)
+			return
+		}
+	}
+
+	label := strings.Join(input.InstanceTypes, "-")
+	if label == "" {
+		label = "InstanceRequirements"
+	}
+	result.PhysicalResourceId = physicalResourceId(ctx, label)
+
+	var subnetDetails []types.Subnet
+	subnetDetails, err = GetSubnets(ctx, input.Subnets, svc)
 	if err != nil {
 		log.Printf("Error getting subnet details: %v", err)
 		return
 	}
-	azKeys := make([]string, 0, len(azMap))
-	for k := range azMap {
-		azKeys = append(azKeys, k)
+	if err = CheckSubnetsFound(input.Subnets, subnetDetails, input.Region); err != nil {
+		return
 	}
-	// Describe instance type offerings
-	input := &ec2.DescribeInstanceTypeOfferingsInput{
-		LocationType: types.LocationTypeAvailabilityZone,
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("instance-type"),
-				Values: []string{instanceType},
-			},
-			{
-				Name:   aws.String("location"),
-				Values: azKeys,
-			},
-		},
+	if template.AvailabilityZone != "" {
+		subnetDetails = subnetsInZones(subnetDetails, []string{template.AvailabilityZone})
+		if len(subnetDetails) == 0 {
+			err = fmt.Errorf("launch template %v places instances in %v, which none of the subnets are in", template.LaunchTemplateId, template.AvailabilityZone)
+			return
+		}
+	}
+	if input.ZoneIds != nil {
+		subnetDetails = subnetsInZoneIds(subnetDetails, input.ZoneIds)
+		if len(subnetDetails) == 0 {
+			err = fmt.Errorf("none of the subnets are in %v", input.ZoneIds)
+			return
+		}
 	}
 
-	log.Printf("DescribeInstanceTypeOfferings input: %#v", input)
+	// Subnets shared from another account need the account to be allowed to put network interfaces in them
+	result.AccountId, err = GetCallerAccount(ctx, stsSvc)
+	if err != nil {
+		return
+	}
+	shared := SharedSubnets(subnetDetails, result.AccountId)
+	sharedIds := make([]string, len(shared))
+	for i, subnet := range shared {
+		sharedIds[i] = aws.ToString(subnet.SubnetId)
+	}
+	if len(shared) > 0 && len(input.SecurityGroupIds) == 0 {
+		// Without security groups the network interface would get the VPC's default group, which is the owner's, so
+		// every shared subnet would fail
+		log.Printf("Warning: not checking the account can use shared subnets %v, as that needs SecurityGroupIds", sharedIds)
+	} else if len(shared) > 0 {
+		var passed []string
+		passed, result.SharedSubnetFailures, err = CheckSharedSubnetLaunchPermission(ctx, sharedIds, input.SecurityGroupIds, svc)
+		if err != nil {
+			return
+		}
+		if len(result.SharedSubnetFailures) > 0 {
+			usable := subnetDetails[:0]
+			for _, subnet := range subnetDetails {
+				if _, failed := result.SharedSubnetFailures[aws.ToString(subnet.SubnetId)]; !failed {
+					usable = append(usable, subnet)
+				}
+			}
+			subnetDetails = usable
+		}
+		if len(subnetDetails) == 0 {
+			err = sharedSubnetFailuresError(result.AccountId, result.SharedSubnetFailures)
+			return
+		}
+		log.Printf("Can use shared subnets %v", passed)
+	}
 
-	result, err := svc.DescribeInstanceTypeOfferings(ctx, input)
+	// Local and Wavelength Zones have offerings like availability zones, but an Outpost's instance types are its own
+	var subnetZones map[string]SubnetZone
+	subnetZones, err = GetSubnetZones(ctx, subnetDetails, svc)
 	if err != nil {
-		log.Printf("Error describing instance type offerings: %v", err)
 		return
 	}
+	// Zones are looked up by zone ID, as a zone name can be a different zone in another account (e.g. the owner of a
+	// shared subnet, or the account of a paired service)
+	var outpostSubnets []types.Subnet
+	azMap := make(map[string]string, len(subnetDetails))
+	zoneIds := make(map[string]string, len(subnetDetails))
+	allZoneIds := make(map[string]string, len(subnetDetails))
+	for _, subnet := range subnetDetails {
+		allZoneIds[*subnet.AvailabilityZone] = aws.ToString(subnet.AvailabilityZoneId)
+		if subnet.OutpostArn != nil {
+			outpostSubnets = append(outpostSubnets, subnet)
+			continue
+		}
+		azMap[*subnet.AvailabilityZone] = *subnet.SubnetId
+		zoneIds[*subnet.AvailabilityZone] = aws.ToString(subnet.AvailabilityZoneId)
+	}
+	log.Printf("Found %d subnets", len(azMap))
+
+	// A VPC with dedicated tenancy launches every instance as dedicated
+	if err = validateTenancy(input.Tenancy); err != nil {
+		return
+	}
+	result.Tenancy = input.Tenancy
+	if result.Tenancy != TenancyHost {
+		var vpcTenancy string
+		vpcTenancy, err = GetVpcTenancy(ctx, subnetDetails, svc)
+		if err != nil {
+			return
+		}
+		if result.Tenancy == "" || vpcTenancy == TenancyDedicated {
+			result.Tenancy = vpcTenancy
+		}
+	}
+	// Expand the patterns to the concrete instance types, in order of preference
+	var candidates []types.InstanceTypeInfo
+	candidates, err = ExpandInstanceTypes(ctx, input.InstanceTypes, svc)
+	if err != nil {
+		log.Printf("Error expanding instance types: %v", err)
+		return
+	}
+	// Fail on typos rather than returning no zones
+	if err = ValidateInstanceTypes(ctx, input.InstanceTypes, candidates, svc); err != nil {
+		log.Printf("Error validating instance types: %v", err)
+		return
+	}
+	if input.Requirements != nil {
+		var required []types.InstanceTypeInfo
+		required, err = GetInstanceTypesFromRequirements(ctx, *input.Requirements, svc)
+		if err != nil {
+			log.Printf("Error getting instance types from requirements: %v", err)
+			return
+		}
+		candidates = append(candidates, required...)
+	}
+
+	// Drop the candidates that can't be used with the rest of the input
+	var checks []func(types.InstanceTypeInfo) error
+	if input.ImageId != "" {
+		var image types.Image
+		image, err = GetImage(ctx, input.ImageId, svc)
+		if err != nil {
+			return
+		}
+		checks = append(checks, func(info types.InstanceTypeInfo) error {
+			return CheckImageCompatibility(image, info)
+		})
+	}
+	if input.VolumeType != "" || input.Iops > 0 || input.Throughput > 0 {
+		volume := EbsVolume{VolumeType: input.VolumeType, Iops: input.Iops, Throughput: input.Throughput}
+		if err = volume.Validate(); err != nil {
+			return
+		}
+		checks = append(checks, func(info types.InstanceTypeInfo) error {
+			return CheckEbsCompatibility(volume, info)
+		})
+	}
+	if result.Tenancy == TenancyHost {
+		checks = append(checks, func(info types.InstanceTypeInfo) error {
+			return CheckTenancyCompatibility(result.Tenancy, info)
+		})
+	}
+	if input.PlacementGroupStrategy != "" || input.RequireEFA {
+		if err = validatePlacementStrategy(input.PlacementGroupStrategy); err != nil {
+			return
+		}
+		checks = append(checks, func(info types.InstanceTypeInfo) error {
+			return CheckPlacementCompatibility(input.PlacementGroupStrategy, input.RequireEFA, info)
+		})
+	}
+	for _, check := range checks {
+		var reasons []string
+		candidates, reasons = FilterInstanceTypes(candidates, check)
+		if len(candidates) == 0 && len(reasons) > 0 {
+			err = noneCompatibleError(reasons)
+			return
+		}
+	}
+	// compatible - the checks together, for the equivalent types
+	compatible := func(info types.InstanceTypeInfo) error {
+		for _, check := range checks {
+			if err := check(info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ranked := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		if instanceType := string(candidate.InstanceType); !seen[instanceType] {
+			seen[instanceType] = true
+			ranked = append(ranked, instanceType)
+		}
+	}
+	if len(ranked) == 0 && input.Requirements != nil {
+		err = fmt.Errorf("no instance types meet the InstanceRequirements")
+		return
+	}
+
+	var offerings []types.InstanceTypeOffering
+	if len(zoneIds) > 0 {
+		offerings, err = GetInstanceTypeOfferingsByZoneId(ctx, ranked, zoneIds, svc)
+		if err != nil {
+			log.Printf("Error describing instance type offerings: %v", err)
+			return
+		}
+	}
+	outpostTypes := make(map[string][]string)
+	for _, subnet := range outpostSubnets {
+		outpostArn := aws.ToString(subnet.OutpostArn)
+		if _, ok := outpostTypes[outpostArn]; ok {
+			continue
+		}
+		outpostTypes[outpostArn], err = GetOutpostInstanceTypes(ctx, outpostArn, outpostsSvc)
+		if err != nil {
+			return
+		}
+	}
 
-	log.Printf("DescribeInstanceTypeOfferings result: %v", result)
+	result.SelectedInstanceType, result.MatchedInstanceTypes, result.InstanceTypesByAZ = SelectInstanceType(ranked, offerings)
+	// With only Outpost subnets, take the most preferred type an Outpost has
+	for _, instanceType := range ranked {
+		if result.SelectedInstanceType != "" {
+			break
+		}
+		for _, instanceTypes := range outpostTypes {
+			if slices.Contains(instanceTypes, instanceType) {
+				result.SelectedInstanceType = instanceType
+				result.MatchedInstanceTypes = []string{instanceType}
+				break
+			}
+		}
+	}
+	log.Printf("Selected %v from %v", result.SelectedInstanceType, result.MatchedInstanceTypes)
+	for _, candidate := range candidates {
+		if string(candidate.InstanceType) == result.SelectedInstanceType {
+			result.Attributes = GetInstanceTypeAttributes(candidate)
+			break
+		}
+	}
 
-	// Collect available zones
-	for _, offering := range result.InstanceTypeOfferings {
+	// Collect the zones the selected type is available in, in the order they were returned
+	for _, offering := range offerings {
+		if string(offering.InstanceType) != result.SelectedInstanceType {
+			continue
+		}
 		log.Printf("Adding %v to availableZones", *offering.Location)
-		availableZones = append(availableZones, *offering.Location)
+		result.AvailableZones = append(result.AvailableZones, *offering.Location)
 	}
 
-	log.Printf("Available zones: %v", availableZones)
+	log.Printf("Available zones: %v", result.AvailableZones)
+
+	// Score and price the zones for spot, then order them by the selection strategy
+	if input.SpotTargetCapacity > 0 && len(result.AvailableZones) > 0 {
+		var scoresById map[string]int32
+		scoresById, err = GetSpotPlacementScores(ctx, result.MatchedInstanceTypes, input.SpotTargetCapacity, input.Region, svc)
+		if err != nil {
+			log.Printf("Error getting spot placement scores: %v", err)
+			return
+		}
+		result.SpotPlacementScores = make(map[string]int32, len(result.AvailableZones))
+		for _, az := range result.AvailableZones {
+			result.SpotPlacementScores[az] = scoresById[zoneIds[az]]
+		}
+	}
+	// The spot price history only has zone names, which are in this account's mapping like the subnets' zones
+	if (input.IncludeSpotPrice || input.SelectionStrategy == SelectionStrategyLowestSpotPrice) && len(result.AvailableZones) > 0 {
+		result.SpotPrices, err = GetSpotPrices(ctx, result.SelectedInstanceType, input.SpotProductDescription, result.AvailableZones, svc)
+		if err != nil {
+			log.Printf("Error getting spot prices: %v", err)
+			return
+		}
+	}
+	if err = OrderZones(result.AvailableZones, input.SelectionStrategy, result.SpotPlacementScores, result.SpotPrices); err != nil {
+		return
+	}
+	log.Printf("Zones in %v order: %v", input.SelectionStrategy, result.AvailableZones)
+
+	// Suggest replacements when the selected (or, if nothing is offered, the preferred) type is short of zones
+	minimumAZs := input.MinimumAZs
+	if minimumAZs <= 0 || minimumAZs > len(zoneIds) {
+		minimumAZs = len(zoneIds)
+	}
+	result.EquivalentInstanceTypes = []string{}
+	if len(result.AvailableZones) < minimumAZs && len(candidates) > 0 {
+		reference := candidates[0]
+		for _, candidate := range candidates {
+			if string(candidate.InstanceType) == result.SelectedInstanceType {
+				reference = candidate
+				break
+			}
+		}
+		result.EquivalentInstanceTypes, err = FindEquivalentInstanceTypes(ctx, reference, compatible, zoneIds, minimumAZs, svc)
+		if err != nil {
+			log.Printf("Error finding equivalent instance types: %v", err)
+			return
+		}
+	}
+
+	// Prefer (or require) the zones where a capacity reservation for the selected type has room
+	if input.CheckCapacityReservations || input.RequireCapacityReservation || input.CapacityReservationGroupArn != "" {
+		if len(result.AvailableZones) > 0 {
+			result.CapacityReservations, err = GetCapacityReservations(ctx, result.SelectedInstanceType, zoneIdsOf(result.AvailableZones, allZoneIds), input.CapacityReservationGroupArn, svc)
+			if err != nil {
+				return
+			}
+		}
+		if input.RequireCapacityReservation {
+			reserved := []string{}
+			for _, az := range result.AvailableZones {
+				if _, ok := result.CapacityReservations[az]; ok {
+					reserved = append(reserved, az)
+				}
+			}
+			if len(reserved) == 0 {
+				err = fmt.Errorf("no active capacity reservation with free capacity for %v in %v", result.SelectedInstanceType, result.AvailableZones)
+				return
+			}
+			result.AvailableZones = reserved
+		}
+		PreferCapacityReservations(result.AvailableZones, result.CapacityReservations)
+		log.Printf("Zones with capacity reservations first: %v", result.AvailableZones)
+	}
+
+	// Dedicated Host instances can only go in the zones with a host that has room for them
+	if result.Tenancy == TenancyHost && len(result.AvailableZones) > 0 {
+		result.Hosts, err = GetAvailableHosts(ctx, result.SelectedInstanceType, zoneIdsOf(result.AvailableZones, allZoneIds), svc)
+		if err != nil {
+			return
+		}
+		zones := []string{}
+		for _, az := range result.AvailableZones {
+			if len(result.Hosts[az]) > 0 {
+				zones = append(zones, az)
+			}
+		}
+		if len(zones) == 0 {
+			err = fmt.Errorf("no available Dedicated Host with capacity for %v in %v", result.SelectedInstanceType, result.AvailableZones)
+			return
+		}
+		result.AvailableZones = zones
+	}
 
 	// Now loop through the available zones and get the subnets
-	for _, az := range availableZones {
+	for _, az := range result.AvailableZones {
 		if subnet, ok := azMap[az]; ok {
-			availableSubnets = append(availableSubnets, subnet)
+			result.AvailableSubnets = append(result.AvailableSubnets, subnet)
 		}
 	}
+	// Then add the Outpost subnets whose Outpost has the selected type
+	for _, subnet := range outpostSubnets {
+		if !slices.Contains(outpostTypes[aws.ToString(subnet.OutpostArn)], result.SelectedInstanceType) {
+			continue
+		}
+		result.AvailableSubnets = append(result.AvailableSubnets, aws.ToString(subnet.SubnetId))
+		if az := aws.ToString(subnet.AvailabilityZone); !slices.Contains(result.AvailableZones, az) {
+			result.AvailableZones = append(result.AvailableZones, az)
+		}
+	}
+
+	// Drop the subnets (and their zones) EC2 wouldn't launch the selected type in
+	if input.DryRunLaunch && len(result.AvailableSubnets) > 0 {
+		if input.ImageId == "" && input.LaunchTemplateId == "" {
+			err = fmt.Errorf("DryRunLaunch requires ImageId or LaunchTemplateId")
+			return
+		}
+		spec := LaunchSpec{
+			ImageId:               input.ImageId,
+			KeyName:               input.KeyName,
+			SecurityGroupIds:      input.SecurityGroupIds,
+			IamInstanceProfile:    input.IamInstanceProfile,
+			LaunchTemplateId:      input.LaunchTemplateId,
+			LaunchTemplateVersion: template.Version,
+			NetworkInterfaces:     template.NetworkInterfaces,
+		}
+		var passed []string
+		passed, result.DryRunFailures, err = DryRunLaunch(ctx, result.SelectedInstanceType, result.AvailableSubnets, spec, svc)
+		if err != nil {
+			return
+		}
+		if len(passed) == 0 {
+			err = dryRunFailuresError(result.SelectedInstanceType, result.DryRunFailures)
+			return
+		}
+		result.AvailableZones, result.AvailableSubnets = zonesWithSubnets(result.AvailableZones, passed, subnetZones), passed
+	}
+
+	// A cluster placement group can't span zones, so keep the best one
+	if input.PlacementGroupStrategy == PlacementStrategyCluster && len(result.AvailableZones) > 1 {
+		result.AvailableZones = result.AvailableZones[:1]
+		result.AvailableSubnets = subnetIdsInZones(result.AvailableSubnets, result.AvailableZones, subnetZones)
+		log.Printf("Cluster placement group in %v", result.AvailableZones[0])
+	}
+
+	// The matched types that can be used as overrides across all of the zones
+	result.LaunchTemplateOverrides = GetLaunchTemplateOverrides(candidates, result.InstanceTypesByAZ, result.AvailableZones)
 
 	// Get the first subnet ID and availability zone
-	if len(availableSubnets) > 0 {
-		firstSubnetId = availableSubnets[0]
+	if len(result.AvailableSubnets) > 0 {
+		result.FirstSubnetId = result.AvailableSubnets[0]
 	}
-	if len(availableZones) > 0 {
-		firstAZ = availableZones[0]
+	if len(result.AvailableZones) > 0 {
+		result.FirstAZ = result.AvailableZones[0]
+	}
+	owners := make(map[string]string, len(subnetDetails))
+	for _, subnet := range subnetDetails {
+		owners[aws.ToString(subnet.SubnetId)] = aws.ToString(subnet.OwnerId)
+	}
+	result.SubnetZones = make(map[string]SubnetZone, len(result.AvailableSubnets))
+	result.SubnetOwners = make(map[string]string, len(result.AvailableSubnets))
+	for _, subnet := range result.AvailableSubnets {
+		result.SubnetZones[subnet] = subnetZones[subnet]
+		result.SubnetOwners[subnet] = owners[subnet]
 	}
 	// Get the next available IP address in the first subnet
-	if len(availableSubnets) > 0 {
+	if len(result.AvailableSubnets) > 0 {
 		// describe the subnet to get the CIDR block
 		subnetInput := &ec2.DescribeSubnetsInput{
-			SubnetIds: []string{firstSubnetId},
+			SubnetIds: []string{result.FirstSubnetId},
 		}
 		var subnetDetails *ec2.DescribeSubnetsOutput
 		subnetDetails, err = svc.DescribeSubnets(ctx, subnetInput)
@@ -106,12 +720,12 @@ func GetTypeAvailabilityZones(ctx context.Context, instanceType string, subnets
 		}
 		// Get the next available IP address by using the cidr block of the subnet, and
 		// stepping through the addresses until one is not in use (0-3 and 255 are reserved)
-		nextIP, err = GetNextAvailableIP(*subnetDetails.Subnets[0].CidrBlock, svc)
+		result.NextIP, err = GetNextAvailableIP(*subnetDetails.Subnets[0].CidrBlock, svc)
 	}
 	return
 }
 
-func GetNextAvailableIP(cidrBlock string, svc *ec2.Client) (string, error) {
+func GetNextAvailableIP(cidrBlock string, svc EC2Client) (string, error) {
 	// Use the cidr to get the fourth IP address
 	_, ipnet, err := net.ParseCIDR(cidrBlock)
 	if err != nil {
@@ -138,7 +752,7 @@ func GetNextAvailableIP(cidrBlock string, svc *ec2.Client) (string, error) {
 }
 
 // isIPInUse - Check if the IP address is in use
-func isIPInUse(ip string, svc *ec2.Client) (bool, error) {
+func isIPInUse(ip string, svc EC2Client) (bool, error) {
 	// Implement the logic to check if the IP address is in use
 	// This can be done by describing the network interfaces and checking the private IP addresses
 	input := &ec2.DescribeNetworkInterfacesInput{
@@ -158,10 +772,98 @@ func isIPInUse(ip string, svc *ec2.Client) (bool, error) {
 	return len(result.NetworkInterfaces) > 0, nil
 }
 
+// subnetsInZones - the subnets in any of the zones
+func subnetsInZones(subnets []types.Subnet, zones []string) (inZones []types.Subnet) {
+	for _, subnet := range subnets {
+		for _, zone := range zones {
+			if aws.ToString(subnet.AvailabilityZone) == zone {
+				inZones = append(inZones, subnet)
+				break
+			}
+		}
+	}
+	return
+}
+
+// subnetsInZoneIds - the subnets, in order, that are in any of the zones with these IDs
+func subnetsInZoneIds(subnets []types.Subnet, zoneIds []string) (inZones []types.Subnet) {
+	for _, subnet := range subnets {
+		if slices.Contains(zoneIds, aws.ToString(subnet.AvailabilityZoneId)) {
+			inZones = append(inZones, subnet)
+		}
+	}
+	return
+}
+
+// CheckSubnetsFound - Check all the requested subnets were found, which they won't be when they are in another region
+// (or account)
+func CheckSubnetsFound(requested []string, subnets []types.Subnet, region string) error {
+	var missing []string
+	for _, subnetId := range requested {
+		if !slices.ContainsFunc(subnets, func(subnet types.Subnet) bool { return aws.ToString(subnet.SubnetId) == subnetId }) {
+			missing = append(missing, subnetId)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if region == "" {
+		region = "the configured region"
+	}
+	return fmt.Errorf("subnets %v not found in %v, check Region is the region they are in", missing, region)
+}
+
+// zoneIdsOf - the zone IDs of the zones, by zone name
+func zoneIdsOf(zones []string, zoneIds map[string]string) map[string]string {
+	ids := make(map[string]string, len(zones))
+	for _, zone := range zones {
+		ids[zone] = zoneIds[zone]
+	}
+	return ids
+}
+
+// zonesWithSubnets - the zones, in order, that at least one of the subnets is in (an Outpost subnet is in its parent
+// zone)
+func zonesWithSubnets(zones []string, subnets []string, subnetZones map[string]SubnetZone) (kept []string) {
+	for _, zone := range zones {
+		if slices.ContainsFunc(subnets, func(subnet string) bool { return subnetZones[subnet].ZoneName == zone }) {
+			kept = append(kept, zone)
+		}
+	}
+	return
+}
+
+// subnetIdsInZones - the subnets, in order, that are in any of the zones (an Outpost subnet is in its parent zone)
+func subnetIdsInZones(subnets []string, zones []string, subnetZones map[string]SubnetZone) (inZones []string) {
+	for _, subnet := range subnets {
+		if slices.Contains(zones, subnetZones[subnet].ZoneName) {
+			inZones = append(inZones, subnet)
+		}
+	}
+	return
+}
+
 // GetSubnetDetails - Get the details of the subnets in the given availability zones
 func GetSubnetDetails(subnets []string, svc EC2Client) (returnAZ map[string]string, err error) {
 	returnAZ = make(map[string]string)
 	var subnetDetails []types.Subnet
+	subnetDetails, err = GetSubnets(context.Background(), subnets, svc)
+	if err != nil {
+		return
+	}
+	// Get the subnet IDs as a slice
+	for _, subnet := range subnetDetails {
+		returnAZ[*subnet.AvailabilityZone] = *subnet.SubnetId
+
+	}
+
+	log.Printf("Found %d subnets", len(returnAZ))
+
+	return
+}
+
+// GetSubnets - Describe the subnets
+func GetSubnets(ctx context.Context, subnets []string, svc EC2Client) (subnetDetails []types.Subnet, err error) {
 	var nextToken *string
 	for {
 		subnetInput := &ec2.DescribeSubnetsInput{
@@ -177,7 +879,7 @@ func GetSubnetDetails(subnets []string, svc EC2Client) (returnAZ map[string]stri
 		//log.Printf("DescribeSubnets input: %v", subnetInput)
 
 		var subnetResult *ec2.DescribeSubnetsOutput
-		subnetResult, err = svc.DescribeSubnets(context.Background(), subnetInput)
+		subnetResult, err = svc.DescribeSubnets(ctx, subnetInput)
 		if err != nil {
 			log.Printf("Error describing subnets: %v", err)
 			return
@@ -191,23 +893,17 @@ func GetSubnetDetails(subnets []string, svc EC2Client) (returnAZ map[string]stri
 
 		nextToken = subnetResult.NextToken
 	}
-	// Get the subnet IDs as a slice
-	for _, subnet := range subnetDetails {
-		returnAZ[*subnet.AvailabilityZone] = *subnet.SubnetId
-
-	}
-
-	log.Printf("Found %d subnets", len(returnAZ))
-
 	return
 }
 
 // InstanceTypAZCheck - Lambda function to get the availability zones for a given instance type
 func InstanceTypAZCheck(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
 	log.Printf("InstanceTypAZCheck(%#v, %#v)", ctx, event)
-	lc, _ := lambdacontext.FromContext(ctx)
-	log.Printf("AWSRequestID: %#v", lc.AwsRequestID)
-	physicalResourceID := fmt.Sprintf("InstanceTypAZCheck-%v", lc.AwsRequestID)
+	var requestID string
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		requestID = lc.AwsRequestID
+	}
+	log.Printf("AWSRequestID: %#v", requestID)
 
 	// Handle DELETE immediately - no resources to query or clean up
 	if event.RequestType == "Delete" {
@@ -220,50 +916,27 @@ func InstanceTypAZCheck(ctx context.Context, event cfn.Event) (string, map[strin
 		return physicalResourceID, map[string]interface{}{}, nil
 	}
 
-	instanceType, ok := event.ResourceProperties["InstanceType"].(string)
-	if !ok {
-		err := fmt.Errorf("InstanceType property is missing or invalid")
-		log.Printf("Error: %v", err)
-		return "", nil, err
-	}
-	log.Printf("instance-type: %v", instanceType)
-	physicalResourceID = fmt.Sprintf("InstanceTypAZCheck-%v-%v-%v", instanceType, instanceType, lc.AwsRequestID)
-
-	subnetsInterface, ok := event.ResourceProperties["Subnets"].([]interface{})
-	if !ok {
-		err := fmt.Errorf("Subnets property is missing or invalid")
+	input, err := GetAZCheckInput(event.ResourceProperties)
+	if err != nil {
 		log.Printf("Error: %v", err)
 		return "", nil, err
 	}
-	subnets := make([]string, len(subnetsInterface))
-	for i, v := range subnetsInterface {
-		subnets[i] = v.(string)
-	}
-	log.Printf("subnets: %v", subnets)
 
-	var typeAvailableInZones []string
-	var typeAvailableInSubnetIds []string
-	var firstSubnetId string
-	var firstAZ string
-	var nextSubnetIP string
-	var err error
-	physicalResourceID, typeAvailableInZones, typeAvailableInSubnetIds, firstSubnetId, firstAZ, nextSubnetIP, err = GetTypeAvailabilityZones(ctx, instanceType, subnets)
+	result, err := GetTypeAvailabilityZones(ctx, input)
 	if err != nil {
 		log.Printf("Error getting availability zones: %v", err)
 		return "", nil, err
 	}
 
 	// Build response data - only return arrays of available zones/subnets
-	data := map[string]interface{}{
-		"AvailableInAZs":       typeAvailableInZones,
-		"AvailableInSubnetIds": typeAvailableInSubnetIds,
-		"SubnetId":             firstSubnetId,
-		"AZ":                   firstAZ,
-		"PrivateIP":            nextSubnetIP,
+	data, err := result.Data()
+	if err != nil {
+		log.Printf("Error building response data: %v", err)
+		return "", nil, err
 	}
 
-	log.Printf("Returning: %v, %#v", physicalResourceID, data)
-	return physicalResourceID, data, nil
+	log.Printf("Returning: %v, %#v", result.PhysicalResourceId, data)
+	return result.PhysicalResourceId, data, nil
 }
 
 // compareSlices checks if two slices have the same members
@@ -0,0 +1,150 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// familyPattern - splits an instance family like m7i-flex into its class (m), generation (7) and attributes (i-flex)
+var familyPattern = regexp.MustCompile(`^([a-z]+?)(\d+)([a-z0-9-]*)$`)
+
+// instanceTypeName - the parts of an instance type name
+type instanceTypeName struct {
+	class      string
+	generation int
+	attributes string
+	size       string
+}
+
+// parseInstanceTypeName - Split an instance type like t4g.small into its parts
+func parseInstanceTypeName(instanceType string) (name instanceTypeName) {
+	family, size, _ := strings.Cut(instanceType, ".")
+	name.size = size
+	if parts := familyPattern.FindStringSubmatch(family); parts != nil {
+		name.class = parts[1]
+		name.generation, _ = strconv.Atoi(parts[2])
+		name.attributes = parts[3]
+	} else {
+		name.class = family
+	}
+	return
+}
+
+// similarity - how alike the candidate is to the original, higher is closer
+func similarity(original, candidate types.InstanceTypeInfo) int {
+	a := parseInstanceTypeName(string(original.InstanceType))
+	b := parseInstanceTypeName(string(candidate.InstanceType))
+	score := 0
+	if a.class == b.class {
+		score += 8
+	}
+	if a.size == b.size {
+		score += 4
+	}
+	if aws.ToBool(original.BurstablePerformanceSupported) == aws.ToBool(candidate.BurstablePerformanceSupported) {
+		score += 2
+	}
+	if primaryArchitecture(original) == primaryArchitecture(candidate) {
+		score++
+	}
+	// Prefer the neighbouring generations
+	if a.generation > b.generation {
+		score -= a.generation - b.generation
+	} else {
+		score -= b.generation - a.generation
+	}
+	return score
+}
+
+// FindEquivalentInstanceTypes - Get the instance types with the same vCPUs and memory as the original (e.g. t3, t3a
+// and t4g, or the next and previous generations) that pass the check (if any) and are offered in at least minimumZones
+// of the zones (name to zone ID), most similar first
+func FindEquivalentInstanceTypes(ctx context.Context, original types.InstanceTypeInfo, check func(types.InstanceTypeInfo) error, zoneIds map[string]string, minimumZones int, svc EC2Client) (equivalents []string, err error) {
+	log.Printf("FindEquivalentInstanceTypes(%v, %v, %d)", original.InstanceType, zoneIds, minimumZones)
+	equivalents = []string{}
+	if defaultVCpus(original) == 0 || memoryMiB(original) == 0 {
+		return
+	}
+
+	var candidates []types.InstanceTypeInfo
+	input := &ec2.DescribeInstanceTypesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("vcpu-info.default-vcpus"),
+				Values: []string{fmt.Sprint(defaultVCpus(original))},
+			},
+			{
+				Name:   aws.String("memory-info.size-in-mib"),
+				Values: []string{fmt.Sprint(memoryMiB(original))},
+			},
+		},
+	}
+	for {
+		var result *ec2.DescribeInstanceTypesOutput
+		result, err = svc.DescribeInstanceTypes(ctx, input)
+		if err != nil {
+			log.Printf("Error describing instance types: %v", err)
+			return
+		}
+		for _, info := range result.InstanceTypes {
+			if info.InstanceType != original.InstanceType {
+				candidates = append(candidates, info)
+			}
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	if check != nil {
+		candidates, _ = FilterInstanceTypes(candidates, check)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		names = append(names, string(candidate.InstanceType))
+	}
+	var offerings []types.InstanceTypeOffering
+	offerings, err = GetInstanceTypeOfferingsByZoneId(ctx, names, zoneIds, svc)
+	if err != nil {
+		return
+	}
+	coverage := make(map[string]int)
+	for _, offering := range offerings {
+		coverage[string(offering.InstanceType)]++
+	}
+
+	scores := make(map[string]int, len(candidates))
+	for _, candidate := range candidates {
+		name := string(candidate.InstanceType)
+		if coverage[name] >= minimumZones {
+			scores[name] = similarity(original, candidate)
+			equivalents = append(equivalents, name)
+		}
+	}
+	sort.SliceStable(equivalents, func(i, j int) bool {
+		a, b := equivalents[i], equivalents[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if coverage[a] != coverage[b] {
+			return coverage[a] > coverage[b]
+		}
+		return a < b
+	})
+
+	log.Printf("Equivalents of %v: %v", original.InstanceType, equivalents)
+	return
+}
@@ -0,0 +1,96 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// GetImage - Get the details of the AMI
+func GetImage(ctx context.Context, imageId string, svc EC2Client) (image types.Image, err error) {
+	var result *ec2.DescribeImagesOutput
+	result, err = svc.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{imageId},
+	})
+	if err != nil {
+		log.Printf("Error describing image %v: %v", imageId, err)
+		return
+	}
+	if len(result.Images) == 0 {
+		err = fmt.Errorf("image %s not found", imageId)
+		return
+	}
+	image = result.Images[0]
+	log.Printf("Image %v: architecture %v, boot mode %v, virtualization %v, ENA %v", imageId, image.Architecture,
+		image.BootMode, image.VirtualizationType, aws.ToBool(image.EnaSupport))
+	return
+}
+
+// CheckImageCompatibility - Check the AMI can be launched on the instance type, returning the reason if it can't
+func CheckImageCompatibility(image types.Image, info types.InstanceTypeInfo) error {
+	imageId := aws.ToString(image.ImageId)
+
+	var architectures []types.ArchitectureType
+	if info.ProcessorInfo != nil {
+		architectures = info.ProcessorInfo.SupportedArchitectures
+	}
+	if !slices.Contains(architectures, types.ArchitectureType(image.Architecture)) {
+		return fmt.Errorf("image %s is %s but %s supports %v", imageId, image.Architecture, info.InstanceType, architectures)
+	}
+
+	if image.VirtualizationType != "" && !slices.Contains(info.SupportedVirtualizationTypes, image.VirtualizationType) {
+		return fmt.Errorf("image %s uses %s virtualization but %s supports %v", imageId, image.VirtualizationType,
+			info.InstanceType, info.SupportedVirtualizationTypes)
+	}
+
+	if !bootModeSupported(image, info.SupportedBootModes) {
+		return fmt.Errorf("image %s boots with %s but %s supports %v", imageId, imageBootMode(image),
+			info.InstanceType, info.SupportedBootModes)
+	}
+
+	if image.RootDeviceType != "" && len(info.SupportedRootDeviceTypes) > 0 &&
+		!slices.Contains(info.SupportedRootDeviceTypes, types.RootDeviceType(image.RootDeviceType)) {
+		return fmt.Errorf("image %s has an %s root device but %s supports %v", imageId, image.RootDeviceType,
+			info.InstanceType, info.SupportedRootDeviceTypes)
+	}
+
+	if info.NetworkInfo != nil && info.NetworkInfo.EnaSupport == types.EnaSupportRequired && !aws.ToBool(image.EnaSupport) {
+		return fmt.Errorf("%s requires ENA but image %s does not have ENA support enabled", info.InstanceType, imageId)
+	}
+
+	// DescribeImages doesn't report NVMe drivers, AMIs built for Nitro have ENA enabled so use that as the signal
+	if info.EbsInfo != nil && info.EbsInfo.NvmeSupport == types.EbsNvmeSupportRequired && !aws.ToBool(image.EnaSupport) {
+		return fmt.Errorf("%s requires NVMe EBS volumes but image %s is not built for Nitro (no ENA support)", info.InstanceType, imageId)
+	}
+
+	return nil
+}
+
+// imageBootMode - the boot mode of the image, AMIs without one boot the architecture's default
+func imageBootMode(image types.Image) types.BootModeValues {
+	if image.BootMode != "" {
+		return image.BootMode
+	}
+	if image.Architecture == types.ArchitectureValuesArm64 || image.Architecture == types.ArchitectureValuesArm64Mac {
+		return types.BootModeValuesUefi
+	}
+	return types.BootModeValuesLegacyBios
+}
+
+// bootModeSupported - true if the instance type can boot the image
+func bootModeSupported(image types.Image, supported []types.BootModeType) bool {
+	if len(supported) == 0 {
+		return true
+	}
+	switch mode := imageBootMode(image); mode {
+	case types.BootModeValuesUefiPreferred:
+		return slices.Contains(supported, types.BootModeTypeUefi) || slices.Contains(supported, types.BootModeTypeLegacyBios)
+	default:
+		return slices.Contains(supported, types.BootModeType(mode))
+	}
+}
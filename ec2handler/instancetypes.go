@@ -0,0 +1,225 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// maxFilterValues - the number of instance types passed in a single filter
+const maxFilterValues = 100
+
+// ExpandInstanceTypes - Get the instance types matching the exact types or glob patterns (EC2 accepts * and ? in the
+// instance-type filter). The result is in order of preference: the order of the patterns, then smallest first.
+func ExpandInstanceTypes(ctx context.Context, patterns []string, svc EC2Client) (infos []types.InstanceTypeInfo, err error) {
+	log.Printf("ExpandInstanceTypes(%v)", patterns)
+	if len(patterns) == 0 {
+		return
+	}
+	for start := 0; start < len(patterns); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(patterns))
+		var nextToken *string
+		for {
+			input := &ec2.DescribeInstanceTypesInput{
+				Filters: []types.Filter{
+					{
+						Name:   aws.String("instance-type"),
+						Values: patterns[start:end],
+					},
+				},
+				NextToken: nextToken,
+			}
+			var result *ec2.DescribeInstanceTypesOutput
+			result, err = svc.DescribeInstanceTypes(ctx, input)
+			if err != nil {
+				log.Printf("Error describing instance types: %v", err)
+				return
+			}
+			infos = append(infos, result.InstanceTypes...)
+			if result.NextToken == nil {
+				break
+			}
+			nextToken = result.NextToken
+		}
+	}
+
+	// Rank by the first pattern each type matches, then by size
+	rank := make(map[types.InstanceType]int, len(infos))
+	for _, info := range infos {
+		rank[info.InstanceType] = len(patterns)
+		for i, pattern := range patterns {
+			if ok, _ := path.Match(pattern, string(info.InstanceType)); ok {
+				rank[info.InstanceType] = i
+				break
+			}
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if rank[a.InstanceType] != rank[b.InstanceType] {
+			return rank[a.InstanceType] < rank[b.InstanceType]
+		}
+		return lessBySize(a, b)
+	})
+
+	log.Printf("Expanded %v to %d instance types", patterns, len(infos))
+	return
+}
+
+// lessBySize - orders instance types by vCPUs, then memory, then name
+func lessBySize(a, b types.InstanceTypeInfo) bool {
+	if va, vb := defaultVCpus(a), defaultVCpus(b); va != vb {
+		return va < vb
+	}
+	if ma, mb := memoryMiB(a), memoryMiB(b); ma != mb {
+		return ma < mb
+	}
+	return a.InstanceType < b.InstanceType
+}
+
+// defaultVCpus - the default number of vCPUs for the instance type
+func defaultVCpus(info types.InstanceTypeInfo) int32 {
+	if info.VCpuInfo == nil {
+		return 0
+	}
+	return aws.ToInt32(info.VCpuInfo.DefaultVCpus)
+}
+
+// memoryMiB - the memory of the instance type in MiB
+func memoryMiB(info types.InstanceTypeInfo) int64 {
+	if info.MemoryInfo == nil {
+		return 0
+	}
+	return aws.ToInt64(info.MemoryInfo.SizeInMiB)
+}
+
+// GetInstanceTypeOfferings - Get the offerings of the instance types in the availability zones
+func GetInstanceTypeOfferings(ctx context.Context, instanceTypes []string, zones []string, svc EC2Client) (offerings []types.InstanceTypeOffering, err error) {
+	return describeInstanceTypeOfferings(ctx, types.LocationTypeAvailabilityZone, instanceTypes, zones, svc)
+}
+
+// GetInstanceTypeOfferingsByZoneId - Get the offerings of the instance types in the zones (name to zone ID) by their
+// zone IDs, with the locations given as the zone names. Zone names are mapped to zone IDs differently in each account,
+// so the IDs are what subnets shared from another account agree on.
+func GetInstanceTypeOfferingsByZoneId(ctx context.Context, instanceTypes []string, zoneIds map[string]string, svc EC2Client) (offerings []types.InstanceTypeOffering, err error) {
+	names := zoneNames(zoneIds)
+	ids := make([]string, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	offerings, err = describeInstanceTypeOfferings(ctx, types.LocationTypeAvailabilityZoneId, instanceTypes, ids, svc)
+	for i, offering := range offerings {
+		offerings[i].Location = aws.String(names[aws.ToString(offering.Location)])
+		offerings[i].LocationType = types.LocationTypeAvailabilityZone
+	}
+	return
+}
+
+// zoneNames - the zone names by zone ID, from the zone IDs by name
+func zoneNames(zoneIds map[string]string) map[string]string {
+	names := make(map[string]string, len(zoneIds))
+	for name, id := range zoneIds {
+		names[id] = name
+	}
+	return names
+}
+
+// describeInstanceTypeOfferings - Get the offerings of the instance types in the locations of the location type
+func describeInstanceTypeOfferings(ctx context.Context, locationType types.LocationType, instanceTypes []string, locations []string, svc EC2Client) (offerings []types.InstanceTypeOffering, err error) {
+	for start := 0; start < len(instanceTypes); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(instanceTypes))
+		var nextToken *string
+		for {
+			input := &ec2.DescribeInstanceTypeOfferingsInput{
+				LocationType: locationType,
+				Filters: []types.Filter{
+					{
+						Name:   aws.String("instance-type"),
+						Values: instanceTypes[start:end],
+					},
+					{
+						Name:   aws.String("location"),
+						Values: locations,
+					},
+				},
+				NextToken: nextToken,
+			}
+
+			log.Printf("DescribeInstanceTypeOfferings input: %#v", input)
+
+			var result *ec2.DescribeInstanceTypeOfferingsOutput
+			result, err = svc.DescribeInstanceTypeOfferings(ctx, input)
+			if err != nil {
+				log.Printf("Error describing instance type offerings: %v", err)
+				return
+			}
+
+			log.Printf("DescribeInstanceTypeOfferings result: %v", result)
+
+			offerings = append(offerings, result.InstanceTypeOfferings...)
+			if result.NextToken == nil {
+				break
+			}
+			nextToken = result.NextToken
+		}
+	}
+	return
+}
+
+// SelectInstanceType - Choose the instance type offered in the most zones, ties going to the earlier of the ranked
+// types. Also returns the ranked types that are offered anywhere, and the ranked types offered in each zone.
+func SelectInstanceType(ranked []string, offerings []types.InstanceTypeOffering) (selected string, matched []string, byAZ map[string][]string) {
+	zonesByType := make(map[string]map[string]bool)
+	for _, offering := range offerings {
+		instanceType := string(offering.InstanceType)
+		if zonesByType[instanceType] == nil {
+			zonesByType[instanceType] = make(map[string]bool)
+		}
+		zonesByType[instanceType][aws.ToString(offering.Location)] = true
+	}
+
+	matched = []string{}
+	byAZ = make(map[string][]string)
+	for _, instanceType := range ranked {
+		zones := zonesByType[instanceType]
+		if len(zones) == 0 {
+			continue
+		}
+		matched = append(matched, instanceType)
+		if len(zones) > len(zonesByType[selected]) {
+			selected = instanceType
+		}
+		for zone := range zones {
+			byAZ[zone] = append(byAZ[zone], instanceType)
+		}
+	}
+	return
+}
+
+// FilterInstanceTypes - Keep the instance types that pass the check, returning the reasons for the ones that don't
+func FilterInstanceTypes(infos []types.InstanceTypeInfo, check func(types.InstanceTypeInfo) error) (kept []types.InstanceTypeInfo, reasons []string) {
+	for _, info := range infos {
+		if err := check(info); err != nil {
+			log.Printf("Dropping %v: %v", info.InstanceType, err)
+			reasons = append(reasons, err.Error())
+			continue
+		}
+		kept = append(kept, info)
+	}
+	return
+}
+
+// noneCompatibleError - the error when every instance type failed a check
+func noneCompatibleError(reasons []string) error {
+	if len(reasons) == 1 {
+		return fmt.Errorf("%s", reasons[0])
+	}
+	return fmt.Errorf("no compatible instance types: %s", strings.Join(reasons, "; "))
+}
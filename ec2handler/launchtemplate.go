@@ -0,0 +1,136 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// defaultLaunchTemplateVersion - the version used when none is given
+const defaultLaunchTemplateVersion = "$Default"
+
+// LaunchTemplate - the settings of a launch template version that the check uses
+type LaunchTemplate struct {
+	LaunchTemplateId string
+	// Version is the version number $Default or $Latest resolved to
+	Version      string
+	InstanceType string
+	Requirements *InstanceRequirements
+	// ImageId has any SSM parameter alias resolved
+	ImageId            string
+	KeyName            string
+	SecurityGroupIds   []string
+	IamInstanceProfile string
+	// SubnetId and AvailabilityZone are set when the template pins the network or placement
+	SubnetId         string
+	AvailabilityZone string
+	// NetworkInterfaces is true when the template defines the primary network interface, in which case the subnet
+	// has to be given on the interface rather than on the instance
+	NetworkInterfaces bool
+}
+
+// GetLaunchTemplate - Get the settings of the launch template version, $Default if no version is given
+func GetLaunchTemplate(ctx context.Context, launchTemplateId string, version string, svc EC2Client) (template LaunchTemplate, err error) {
+	log.Printf("GetLaunchTemplate(%v, %v)", launchTemplateId, version)
+	if version == "" {
+		version = defaultLaunchTemplateVersion
+	}
+	var result *ec2.DescribeLaunchTemplateVersionsOutput
+	result, err = svc.DescribeLaunchTemplateVersions(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(launchTemplateId),
+		Versions:         []string{version},
+		ResolveAlias:     aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("Error describing launch template versions: %v", err)
+		return
+	}
+	if len(result.LaunchTemplateVersions) == 0 || result.LaunchTemplateVersions[0].LaunchTemplateData == nil {
+		err = fmt.Errorf("launch template %v has no version %v", launchTemplateId, version)
+		return
+	}
+	ltVersion := result.LaunchTemplateVersions[0]
+	data := ltVersion.LaunchTemplateData
+
+	template = LaunchTemplate{
+		LaunchTemplateId: launchTemplateId,
+		Version:          strconv.FormatInt(aws.ToInt64(ltVersion.VersionNumber), 10),
+		InstanceType:     string(data.InstanceType),
+		ImageId:          aws.ToString(data.ImageId),
+		KeyName:          aws.ToString(data.KeyName),
+		SecurityGroupIds: data.SecurityGroupIds,
+	}
+	if data.InstanceRequirements != nil {
+		template.Requirements = launchTemplateRequirements(*data.InstanceRequirements)
+	}
+	if data.IamInstanceProfile != nil {
+		template.IamInstanceProfile = aws.ToString(data.IamInstanceProfile.Arn)
+		if template.IamInstanceProfile == "" {
+			template.IamInstanceProfile = aws.ToString(data.IamInstanceProfile.Name)
+		}
+	}
+	for _, networkInterface := range data.NetworkInterfaces {
+		if aws.ToInt32(networkInterface.DeviceIndex) != 0 {
+			continue
+		}
+		template.NetworkInterfaces = true
+		template.SubnetId = aws.ToString(networkInterface.SubnetId)
+		if len(networkInterface.Groups) > 0 {
+			template.SecurityGroupIds = networkInterface.Groups
+		}
+	}
+	if data.Placement != nil {
+		template.AvailabilityZone = aws.ToString(data.Placement.AvailabilityZone)
+	}
+	log.Printf("Launch template %v version %v: %+v", launchTemplateId, template.Version, template)
+	return
+}
+
+// launchTemplateRequirements - the vCPU, memory, burstable and generation parts of the template's attribute-based
+// instance type selection
+func launchTemplateRequirements(requirements types.InstanceRequirements) *InstanceRequirements {
+	result := &InstanceRequirements{BurstablePerformance: string(requirements.BurstablePerformance)}
+	if requirements.VCpuCount != nil {
+		result.VCpuMin = aws.ToInt32(requirements.VCpuCount.Min)
+		result.VCpuMax = aws.ToInt32(requirements.VCpuCount.Max)
+	}
+	if requirements.MemoryMiB != nil {
+		result.MemoryMiBMin = aws.ToInt32(requirements.MemoryMiB.Min)
+		result.MemoryMiBMax = aws.ToInt32(requirements.MemoryMiB.Max)
+	}
+	for _, generation := range requirements.InstanceGenerations {
+		result.InstanceGenerations = append(result.InstanceGenerations, string(generation))
+	}
+	return result
+}
+
+// apply - Fill in the parts of the input that weren't given from the template
+func (t LaunchTemplate) apply(input AZCheckInput) AZCheckInput {
+	if len(input.InstanceTypes) == 0 && input.Requirements == nil {
+		if t.InstanceType != "" {
+			input.InstanceTypes = []string{t.InstanceType}
+		}
+		input.Requirements = t.Requirements
+	}
+	if len(input.Subnets) == 0 && t.SubnetId != "" {
+		input.Subnets = []string{t.SubnetId}
+	}
+	if input.ImageId == "" {
+		input.ImageId = t.ImageId
+	}
+	if input.KeyName == "" {
+		input.KeyName = t.KeyName
+	}
+	if len(input.SecurityGroupIds) == 0 {
+		input.SecurityGroupIds = t.SecurityGroupIds
+	}
+	if input.IamInstanceProfile == "" {
+		input.IamInstanceProfile = t.IamInstanceProfile
+	}
+	return input
+}
@@ -0,0 +1,285 @@
+package ec2handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Matrix output formats supported by FormatMatrix
+const (
+	MatrixFormatTable    = "table"
+	MatrixFormatCSV      = "csv"
+	MatrixFormatJSON     = "json"
+	MatrixFormatMarkdown = "markdown"
+)
+
+// MatrixZone - an availability zone column in the matrix
+type MatrixZone struct {
+	ZoneName string `json:"ZoneName"`
+	ZoneId   string `json:"ZoneId"`
+}
+
+// MatrixRow - the offerings of a single instance type across the zones of the matrix
+type MatrixRow struct {
+	InstanceType   string          `json:"InstanceType"`
+	Offered        map[string]bool `json:"Offered"`
+	MissingFromAZs []string        `json:"MissingFromAZs"`
+}
+
+// AvailabilityMatrix - instance types by availability zone for a region
+type AvailabilityMatrix struct {
+	Region        string       `json:"Region"`
+	Zones         []MatrixZone `json:"Zones"`
+	InstanceTypes []MatrixRow  `json:"InstanceTypes"`
+}
+
+// Incomplete - true when the instance type is not offered in at least one zone of the matrix
+func (r MatrixRow) Incomplete() bool {
+	return len(r.MissingFromAZs) > 0
+}
+
+// GetAvailabilityMatrix - Get the offerings of the instance types matching the patterns (e.g. t4g.*, m7*.large) in every
+// availability zone of the region the client is configured for. A pattern matching no offered instance type is an
+// error.
+func GetAvailabilityMatrix(ctx context.Context, patterns []string, svc EC2Client) (matrix AvailabilityMatrix, err error) {
+	log.Printf("GetAvailabilityMatrix(%v)", patterns)
+	if len(patterns) == 0 {
+		err = fmt.Errorf("at least one instance type pattern is required")
+		return
+	}
+
+	var zoneResult *ec2.DescribeAvailabilityZonesOutput
+	zoneResult, err = svc.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("zone-type"),
+				Values: []string{"availability-zone"},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error describing availability zones: %v", err)
+		return
+	}
+	zoneNames := make([]string, 0, len(zoneResult.AvailabilityZones))
+	for _, zone := range zoneResult.AvailabilityZones {
+		matrix.Region = aws.ToString(zone.RegionName)
+		matrix.Zones = append(matrix.Zones, MatrixZone{
+			ZoneName: aws.ToString(zone.ZoneName),
+			ZoneId:   aws.ToString(zone.ZoneId),
+		})
+		zoneNames = append(zoneNames, aws.ToString(zone.ZoneName))
+	}
+	sort.Slice(matrix.Zones, func(i, j int) bool { return matrix.Zones[i].ZoneName < matrix.Zones[j].ZoneName })
+	sort.Strings(zoneNames)
+
+	// The instance-type filter accepts wildcards, so the patterns can be passed straight through
+	offered := make(map[string]map[string]bool)
+	var nextToken *string
+	for {
+		input := &ec2.DescribeInstanceTypeOfferingsInput{
+			LocationType: types.LocationTypeAvailabilityZone,
+			Filters: []types.Filter{
+				{
+					Name:   aws.String("instance-type"),
+					Values: patterns,
+				},
+				{
+					Name:   aws.String("location"),
+					Values: zoneNames,
+				},
+			},
+			NextToken: nextToken,
+		}
+		var result *ec2.DescribeInstanceTypeOfferingsOutput
+		result, err = svc.DescribeInstanceTypeOfferings(ctx, input)
+		if err != nil {
+			log.Printf("Error describing instance type offerings: %v", err)
+			return
+		}
+		for _, offering := range result.InstanceTypeOfferings {
+			instanceType := string(offering.InstanceType)
+			if offered[instanceType] == nil {
+				offered[instanceType] = make(map[string]bool)
+			}
+			offered[instanceType][aws.ToString(offering.Location)] = true
+		}
+		if result.NextToken == nil {
+			break
+		}
+		nextToken = result.NextToken
+	}
+
+	instanceTypes := make([]string, 0, len(offered))
+	for instanceType := range offered {
+		instanceTypes = append(instanceTypes, instanceType)
+	}
+	sort.Strings(instanceTypes)
+
+	// A pattern matching nothing is most likely a typo, which would otherwise give an empty or partial matrix
+	var unmatched []string
+	for _, pattern := range patterns {
+		if !slices.ContainsFunc(instanceTypes, func(instanceType string) bool {
+			matched, _ := path.Match(pattern, instanceType)
+			return matched
+		}) {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	if len(unmatched) > 0 {
+		err = fmt.Errorf("no instance type offered in %v matches %v", matrix.Region, unmatched)
+		return
+	}
+
+	for _, instanceType := range instanceTypes {
+		row := MatrixRow{
+			InstanceType:   instanceType,
+			Offered:        make(map[string]bool, len(zoneNames)),
+			MissingFromAZs: []string{},
+		}
+		for _, zoneName := range zoneNames {
+			row.Offered[zoneName] = offered[instanceType][zoneName]
+			if !row.Offered[zoneName] {
+				row.MissingFromAZs = append(row.MissingFromAZs, zoneName)
+			}
+		}
+		matrix.InstanceTypes = append(matrix.InstanceTypes, row)
+	}
+
+	log.Printf("Found %d instance types across %d zones", len(matrix.InstanceTypes), len(matrix.Zones))
+	return
+}
+
+// FormatMatrix - Render the matrix as a table, CSV, JSON or Markdown. Instance types that are missing from at least
+// one zone are highlighted.
+func FormatMatrix(matrix AvailabilityMatrix, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case MatrixFormatTable, "":
+		return formatMatrixTable(matrix), nil
+	case MatrixFormatCSV:
+		return formatMatrixCSV(matrix)
+	case MatrixFormatJSON:
+		out, err := json.MarshalIndent(matrix, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	case MatrixFormatMarkdown, "md":
+		return formatMatrixMarkdown(matrix), nil
+	default:
+		return "", fmt.Errorf("unsupported matrix format %q (use %s, %s, %s or %s)", format,
+			MatrixFormatTable, MatrixFormatCSV, MatrixFormatJSON, MatrixFormatMarkdown)
+	}
+}
+
+// formatMatrixTable - aligned plain text, incomplete types are marked with a *
+func formatMatrixTable(matrix AvailabilityMatrix) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	header := []string{"INSTANCE TYPE"}
+	ids := []string{""}
+	for _, zone := range matrix.Zones {
+		header = append(header, zone.ZoneName)
+		ids = append(ids, zone.ZoneId)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	fmt.Fprintln(w, strings.Join(ids, "\t"))
+
+	incomplete := 0
+	for _, row := range matrix.InstanceTypes {
+		name := row.InstanceType
+		if row.Incomplete() {
+			name += " *"
+			incomplete++
+		}
+		cells := []string{name}
+		for _, zone := range matrix.Zones {
+			if row.Offered[zone.ZoneName] {
+				cells = append(cells, "yes")
+			} else {
+				cells = append(cells, "-")
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	if incomplete > 0 {
+		fmt.Fprintf(&buf, "\n* %d instance type(s) not offered in every zone\n", incomplete)
+	}
+	return buf.String()
+}
+
+// formatMatrixCSV - one row per instance type with a column per zone, plus the zones it is missing from
+func formatMatrixCSV(matrix AvailabilityMatrix) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"InstanceType"}
+	for _, zone := range matrix.Zones {
+		header = append(header, fmt.Sprintf("%s (%s)", zone.ZoneName, zone.ZoneId))
+	}
+	header = append(header, "MissingFromAZs")
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, row := range matrix.InstanceTypes {
+		record := []string{row.InstanceType}
+		for _, zone := range matrix.Zones {
+			record = append(record, fmt.Sprintf("%t", row.Offered[zone.ZoneName]))
+		}
+		record = append(record, strings.Join(row.MissingFromAZs, " "))
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// formatMatrixMarkdown - a GitHub flavoured table, incomplete types are in bold
+func formatMatrixMarkdown(matrix AvailabilityMatrix) string {
+	var sb strings.Builder
+
+	sb.WriteString("| Instance Type |")
+	for _, zone := range matrix.Zones {
+		fmt.Fprintf(&sb, " %s (%s) |", zone.ZoneName, zone.ZoneId)
+	}
+	sb.WriteString("\n|---|")
+	for range matrix.Zones {
+		sb.WriteString(":---:|")
+	}
+	sb.WriteString("\n")
+
+	for _, row := range matrix.InstanceTypes {
+		if row.Incomplete() {
+			fmt.Fprintf(&sb, "| **%s** |", row.InstanceType)
+		} else {
+			fmt.Fprintf(&sb, "| %s |", row.InstanceType)
+		}
+		for _, zone := range matrix.Zones {
+			if row.Offered[zone.ZoneName] {
+				sb.WriteString(" ✅ |")
+			} else {
+				sb.WriteString(" ❌ |")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
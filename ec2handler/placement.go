@@ -0,0 +1,44 @@
+package ec2handler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Placement group strategies
+const (
+	PlacementStrategyCluster   = string(types.PlacementGroupStrategyCluster)
+	PlacementStrategyPartition = string(types.PlacementGroupStrategyPartition)
+	PlacementStrategySpread    = string(types.PlacementGroupStrategySpread)
+)
+
+// validatePlacementStrategy - Check the placement group strategy is one EC2 has
+func validatePlacementStrategy(strategy string) error {
+	switch strategy {
+	case "", PlacementStrategyCluster, PlacementStrategyPartition, PlacementStrategySpread:
+		return nil
+	}
+	return fmt.Errorf("unknown PlacementGroupStrategy %q (use %s, %s or %s)", strategy,
+		PlacementStrategyCluster, PlacementStrategyPartition, PlacementStrategySpread)
+}
+
+// CheckPlacementCompatibility - Check the instance type can be launched in a placement group with the strategy (if
+// any) and has an Elastic Fabric Adapter when one is required, returning the reason if it can't
+func CheckPlacementCompatibility(strategy string, requireEFA bool, info types.InstanceTypeInfo) error {
+	if strategy != "" {
+		var strategies []types.PlacementGroupStrategy
+		if info.PlacementGroupInfo != nil {
+			strategies = info.PlacementGroupInfo.SupportedStrategies
+		}
+		if !slices.Contains(strategies, types.PlacementGroupStrategy(strategy)) {
+			return fmt.Errorf("%s supports %v placement groups, not %s", info.InstanceType, strategies, strategy)
+		}
+	}
+	if requireEFA && (info.NetworkInfo == nil || !aws.ToBool(info.NetworkInfo.EfaSupported)) {
+		return fmt.Errorf("%s does not support EFA", info.InstanceType)
+	}
+	return nil
+}
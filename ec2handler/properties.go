@@ -0,0 +1,192 @@
+package ec2handler
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// getStringProperty - Get a string property from the resource properties
+func getStringProperty(properties map[string]interface{}, name string) (value string, ok bool) {
+	value, ok = properties[name].(string)
+	return
+}
+
+// GetStringListProperty - Get a list property from the resource properties, CloudFormation passes lists as
+// []interface{} but a comma separated string is also accepted. Exported for the other checkers.
+func GetStringListProperty(properties map[string]interface{}, name string) (values []string, ok bool) {
+	switch v := properties[name].(type) {
+	case []interface{}:
+		values = make([]string, len(v))
+		for i, item := range v {
+			if values[i], ok = item.(string); !ok {
+				return nil, false
+			}
+		}
+		return values, true
+	case []string:
+		return v, true
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values, len(values) > 0
+	}
+	return nil, false
+}
+
+// getInt32Property - Get a number property from the resource properties, CloudFormation passes numbers as strings.
+// A missing property is 0.
+func getInt32Property(properties map[string]interface{}, name string) (int32, error) {
+	switch v := properties[name].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int32(v), nil
+	case int:
+		return int32(v), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%s property must be a whole number: %v", name, err)
+		}
+		return int32(i), nil
+	}
+	return 0, fmt.Errorf("%s property must be a whole number", name)
+}
+
+// getBoolProperty - Get a boolean property from the resource properties, CloudFormation passes booleans as strings.
+// A missing property is false.
+func getBoolProperty(properties map[string]interface{}, name string) (bool, error) {
+	switch v := properties[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("%s property must be true or false", name)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("%s property must be true or false", name)
+}
+
+// getInstanceTypesProperty - Get the instance types (or patterns) from either the InstanceTypes list or the
+// InstanceType property. They are optional when InstanceRequirements or a LaunchTemplateId are given.
+func getInstanceTypesProperty(properties map[string]interface{}) ([]string, error) {
+	if _, present := properties["InstanceTypes"]; present {
+		instanceTypes, ok := GetStringListProperty(properties, "InstanceTypes")
+		if !ok || len(instanceTypes) == 0 {
+			return nil, fmt.Errorf("InstanceTypes property is invalid")
+		}
+		return instanceTypes, nil
+	}
+	instanceType, ok := getStringProperty(properties, "InstanceType")
+	if !ok && (properties["InstanceRequirements"] != nil || properties["LaunchTemplateId"] != nil) {
+		return nil, nil
+	}
+	if !ok || instanceType == "" {
+		return nil, fmt.Errorf("InstanceType property is missing or invalid")
+	}
+	return []string{instanceType}, nil
+}
+
+// getInstanceRequirementsProperty - Get the optional InstanceRequirements property
+func getInstanceRequirementsProperty(properties map[string]interface{}) (*InstanceRequirements, error) {
+	value, present := properties["InstanceRequirements"]
+	if !present {
+		return nil, nil
+	}
+	requirementProperties, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("InstanceRequirements property is invalid")
+	}
+	requirements, err := parseInstanceRequirements(requirementProperties)
+	if err != nil {
+		return nil, err
+	}
+	return &requirements, nil
+}
+
+// GetAZCheckInput - Get the input of the check from the custom resource properties
+func GetAZCheckInput(properties map[string]interface{}) (input AZCheckInput, err error) {
+	if input.InstanceTypes, err = getInstanceTypesProperty(properties); err != nil {
+		return
+	}
+	log.Printf("instance-types: %v", input.InstanceTypes)
+	if input.Requirements, err = getInstanceRequirementsProperty(properties); err != nil {
+		return
+	}
+
+	input.LaunchTemplateId, _ = getStringProperty(properties, "LaunchTemplateId")
+	input.LaunchTemplateVersion, _ = getStringProperty(properties, "LaunchTemplateVersion")
+
+	var ok bool
+	input.Subnets, ok = GetStringListProperty(properties, "Subnets")
+	if !ok && input.LaunchTemplateId == "" {
+		err = fmt.Errorf("Subnets property is missing or invalid")
+		return
+	}
+	log.Printf("subnets: %v", input.Subnets)
+
+	input.ImageId, _ = getStringProperty(properties, "ImageId")
+	var minimumAZs, spotTargetCapacity, desiredCount int32
+	if minimumAZs, err = getInt32Property(properties, "MinimumAZs"); err != nil {
+		return
+	}
+	input.MinimumAZs = int(minimumAZs)
+	if spotTargetCapacity, err = getInt32Property(properties, "SpotTargetCapacity"); err != nil {
+		return
+	}
+	input.SpotTargetCapacity = int(spotTargetCapacity)
+	if input.IncludeSpotPrice, err = getBoolProperty(properties, "IncludeSpotPrice"); err != nil {
+		return
+	}
+	input.SpotProductDescription, _ = getStringProperty(properties, "SpotProductDescription")
+	input.SelectionStrategy, _ = getStringProperty(properties, "SelectionStrategy")
+	if input.SelectionStrategy == SelectionStrategySpotPlacementScore && input.SpotTargetCapacity <= 0 {
+		err = fmt.Errorf("SelectionStrategy %s requires SpotTargetCapacity", input.SelectionStrategy)
+		return
+	}
+	if input.CheckCapacityReservations, err = getBoolProperty(properties, "CheckCapacityReservations"); err != nil {
+		return
+	}
+	if input.RequireCapacityReservation, err = getBoolProperty(properties, "RequireCapacityReservation"); err != nil {
+		return
+	}
+	input.CapacityReservationGroupArn, _ = getStringProperty(properties, "CapacityReservationGroupArn")
+	if desiredCount, err = getInt32Property(properties, "DesiredCount"); err != nil {
+		return
+	}
+	input.DesiredCount = int(desiredCount)
+	if input.QuotaWarnOnly, err = getBoolProperty(properties, "QuotaWarnOnly"); err != nil {
+		return
+	}
+	if input.DryRunLaunch, err = getBoolProperty(properties, "DryRunLaunch"); err != nil {
+		return
+	}
+	input.KeyName, _ = getStringProperty(properties, "KeyName")
+	input.SecurityGroupIds, _ = GetStringListProperty(properties, "SecurityGroupIds")
+	input.IamInstanceProfile, _ = getStringProperty(properties, "IamInstanceProfile")
+	input.VolumeType, _ = getStringProperty(properties, "VolumeType")
+	if input.Iops, err = getInt32Property(properties, "Iops"); err != nil {
+		return
+	}
+	if input.Throughput, err = getInt32Property(properties, "Throughput"); err != nil {
+		return
+	}
+	input.PlacementGroupStrategy, _ = getStringProperty(properties, "PlacementGroupStrategy")
+	if input.RequireEFA, err = getBoolProperty(properties, "RequireEFA"); err != nil {
+		return
+	}
+	input.Tenancy, _ = getStringProperty(properties, "Tenancy")
+	input.Region, _ = getStringProperty(properties, "Region")
+	input.RoleArn, _ = getStringProperty(properties, "RoleArn")
+	input.ExternalId, _ = getStringProperty(properties, "ExternalId")
+	return
+}
@@ -0,0 +1,179 @@
+package ec2handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	sqtypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+)
+
+// ServiceQuotasClient is an interface that defines the methods used from the servicequotas.Client.
+type ServiceQuotasClient interface {
+	GetServiceQuota(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error)
+	GetAWSDefaultServiceQuota(ctx context.Context, params *servicequotas.GetAWSDefaultServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error)
+}
+
+// ec2ServiceCode - the Service Quotas service code for EC2
+const ec2ServiceCode = "ec2"
+
+// vCpuQuotaCodes - the Running On-Demand instances vCPU quota for each instance family, by the letters before the
+// generation (u for u-6tb1 and u7i-12tb, im for im4gn)
+var vCpuQuotaCodes = map[string]string{
+	"u":   "L-43DA4232", // High Memory
+	"hpc": "L-F7808C92", // HPC
+	"trn": "L-2C3B7624", // Trn
+	"inf": "L-1945791B", // Inf
+	"dl":  "L-6E869C2A", // DL
+	"g":   "L-DB2E81BA", // G and VT
+	"gr":  "L-DB2E81BA",
+	"vt":  "L-DB2E81BA",
+	"p":   "L-417A185B", // P
+	"x":   "L-7295265B", // X
+	"f":   "L-74FC7D96", // F
+	"a":   "L-1216C47A", // Standard (A, C, D, H, I, M, R, T, Z)
+	"c":   "L-1216C47A",
+	"d":   "L-1216C47A",
+	"h":   "L-1216C47A",
+	"i":   "L-1216C47A",
+	"im":  "L-1216C47A",
+	"is":  "L-1216C47A",
+	"m":   "L-1216C47A",
+	"r":   "L-1216C47A",
+	"t":   "L-1216C47A",
+	"z":   "L-1216C47A",
+}
+
+// VCpuQuota - the vCPU quota the instance type counts against and how much of it is in use
+type VCpuQuota struct {
+	QuotaCode string
+	QuotaName string
+	Limit     float64
+	// Usage is the vCPUs of the running and pending On-Demand instances counting against the quota
+	Usage int32
+	// Required is DesiredCount x the vCPUs of the instance type
+	Required int32
+	// Exceeded is true when Usage + Required is over the Limit
+	Exceeded bool
+	// Warning explains the shortfall when it is reported rather than failing the check
+	Warning string
+}
+
+// GetVCpuQuotaCode - Get the Running On-Demand instances quota code for the instance type, empty if it has none
+// (e.g. mac instances, which run on dedicated hosts)
+func GetVCpuQuotaCode(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	letters := family
+	if end := strings.IndexFunc(family, func(r rune) bool { return r < 'a' || r > 'z' }); end >= 0 {
+		letters = family[:end]
+	}
+	return vCpuQuotaCodes[letters]
+}
+
+// CheckVCpuQuota - Check that desiredCount more instances of the type fit in the account's On-Demand vCPU quota. When
+// they don't the check fails, unless warnOnly is set in which case the result carries a warning instead.
+func CheckVCpuQuota(ctx context.Context, instanceType string, vcpus int32, desiredCount int, warnOnly bool, svc EC2Client, quotas ServiceQuotasClient) (quota VCpuQuota, err error) {
+	log.Printf("CheckVCpuQuota(%v, %d, %d)", instanceType, vcpus, desiredCount)
+	quota.QuotaCode = GetVCpuQuotaCode(instanceType)
+	if quota.QuotaCode == "" {
+		log.Printf("No vCPU quota for %v, skipping the quota check", instanceType)
+		return
+	}
+	quota.Required = vcpus * int32(desiredCount)
+
+	var serviceQuota *sqtypes.ServiceQuota
+	serviceQuota, err = getServiceQuota(ctx, quota.QuotaCode, quotas)
+	if err != nil {
+		return
+	}
+	quota.QuotaName = aws.ToString(serviceQuota.QuotaName)
+	quota.Limit = aws.ToFloat64(serviceQuota.Value)
+
+	quota.Usage, err = getVCpuUsage(ctx, quota.QuotaCode, svc)
+	if err != nil {
+		return
+	}
+
+	log.Printf("%v: %d in use + %d required of %v", quota.QuotaName, quota.Usage, quota.Required, quota.Limit)
+	if float64(quota.Usage+quota.Required) <= quota.Limit {
+		return
+	}
+	quota.Exceeded = true
+	message := fmt.Sprintf("%d x %v needs %d vCPUs but %d of the %v vCPU %q quota (%v) are in use", desiredCount,
+		instanceType, quota.Required, quota.Usage, quota.Limit, quota.QuotaName, quota.QuotaCode)
+	if warnOnly {
+		quota.Warning = message
+		log.Printf("Warning: %v", message)
+		return
+	}
+	err = errors.New(message)
+	return
+}
+
+// getServiceQuota - Get the applied value of the EC2 quota, falling back to the AWS default when the account has
+// never had it applied
+func getServiceQuota(ctx context.Context, quotaCode string, quotas ServiceQuotasClient) (*sqtypes.ServiceQuota, error) {
+	result, err := quotas.GetServiceQuota(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(ec2ServiceCode),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err == nil {
+		return result.Quota, nil
+	}
+	var notFound *sqtypes.NoSuchResourceException
+	if !errors.As(err, &notFound) {
+		log.Printf("Error getting service quota %v: %v", quotaCode, err)
+		return nil, err
+	}
+	defaultResult, err := quotas.GetAWSDefaultServiceQuota(ctx, &servicequotas.GetAWSDefaultServiceQuotaInput{
+		ServiceCode: aws.String(ec2ServiceCode),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err != nil {
+		log.Printf("Error getting default service quota %v: %v", quotaCode, err)
+		return nil, err
+	}
+	return defaultResult.Quota, nil
+}
+
+// getVCpuUsage - Get the vCPUs of the running and pending On-Demand instances that count against the quota
+func getVCpuUsage(ctx context.Context, quotaCode string, svc EC2Client) (usage int32, err error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{string(types.InstanceStateNameRunning), string(types.InstanceStateNamePending)},
+			},
+		},
+	}
+	for {
+		var result *ec2.DescribeInstancesOutput
+		result, err = svc.DescribeInstances(ctx, input)
+		if err != nil {
+			log.Printf("Error describing instances: %v", err)
+			return
+		}
+		for _, reservation := range result.Reservations {
+			for _, instance := range reservation.Instances {
+				// Spot, scheduled and capacity block instances don't count against the On-Demand quotas
+				if instance.InstanceLifecycle != "" || GetVCpuQuotaCode(string(instance.InstanceType)) != quotaCode {
+					continue
+				}
+				if instance.CpuOptions != nil {
+					usage += aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore)
+				}
+			}
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	return
+}
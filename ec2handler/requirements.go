@@ -0,0 +1,123 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// InstanceRequirements - the attributes used to select instance types instead of naming them
+type InstanceRequirements struct {
+	VCpuMin      int32
+	VCpuMax      int32 // 0 for no maximum
+	MemoryMiBMin int32
+	MemoryMiBMax int32 // 0 for no maximum
+	// ArchitectureTypes defaults to x86_64 and arm64
+	ArchitectureTypes []string
+	// BurstablePerformance is included, excluded or required
+	BurstablePerformance string
+	// InstanceGenerations is current and/or previous
+	InstanceGenerations []string
+}
+
+// parseInstanceRequirements - Read the InstanceRequirements property, shaped like the CloudFormation launch template
+// InstanceRequirements with the addition of ArchitectureTypes
+func parseInstanceRequirements(properties map[string]interface{}) (requirements InstanceRequirements, err error) {
+	vcpus, _ := properties["VCpuCount"].(map[string]interface{})
+	if requirements.VCpuMin, err = getInt32Property(vcpus, "Min"); err != nil {
+		return
+	}
+	if requirements.VCpuMax, err = getInt32Property(vcpus, "Max"); err != nil {
+		return
+	}
+	memory, _ := properties["MemoryMiB"].(map[string]interface{})
+	if requirements.MemoryMiBMin, err = getInt32Property(memory, "Min"); err != nil {
+		return
+	}
+	if requirements.MemoryMiBMax, err = getInt32Property(memory, "Max"); err != nil {
+		return
+	}
+	if requirements.VCpuMin < 1 {
+		err = fmt.Errorf("InstanceRequirements VCpuCount Min must be at least 1")
+		return
+	}
+	requirements.ArchitectureTypes, _ = GetStringListProperty(properties, "ArchitectureTypes")
+	requirements.BurstablePerformance, _ = getStringProperty(properties, "BurstablePerformance")
+	requirements.InstanceGenerations, _ = GetStringListProperty(properties, "InstanceGenerations")
+	return
+}
+
+// request - the GetInstanceTypesFromInstanceRequirements input for the requirements
+func (r InstanceRequirements) request() *ec2.GetInstanceTypesFromInstanceRequirementsInput {
+	request := &types.InstanceRequirementsRequest{
+		VCpuCount: &types.VCpuCountRangeRequest{Min: aws.Int32(r.VCpuMin)},
+		MemoryMiB: &types.MemoryMiBRequest{Min: aws.Int32(r.MemoryMiBMin)},
+	}
+	if r.VCpuMax > 0 {
+		request.VCpuCount.Max = aws.Int32(r.VCpuMax)
+	}
+	if r.MemoryMiBMax > 0 {
+		request.MemoryMiB.Max = aws.Int32(r.MemoryMiBMax)
+	}
+	if r.BurstablePerformance != "" {
+		request.BurstablePerformance = types.BurstablePerformance(r.BurstablePerformance)
+	}
+	for _, generation := range r.InstanceGenerations {
+		request.InstanceGenerations = append(request.InstanceGenerations, types.InstanceGeneration(generation))
+	}
+
+	architectures := []types.ArchitectureType{types.ArchitectureTypeX8664, types.ArchitectureTypeArm64}
+	if len(r.ArchitectureTypes) > 0 {
+		architectures = nil
+		for _, architecture := range r.ArchitectureTypes {
+			architectures = append(architectures, types.ArchitectureType(architecture))
+		}
+	}
+
+	return &ec2.GetInstanceTypesFromInstanceRequirementsInput{
+		ArchitectureTypes:    architectures,
+		VirtualizationTypes:  []types.VirtualizationType{types.VirtualizationTypeHvm},
+		InstanceRequirements: request,
+	}
+}
+
+// GetInstanceTypesFromRequirements - Get the instance types that meet the requirements, smallest first
+func GetInstanceTypesFromRequirements(ctx context.Context, requirements InstanceRequirements, svc EC2Client) (infos []types.InstanceTypeInfo, err error) {
+	log.Printf("GetInstanceTypesFromRequirements(%+v)", requirements)
+	return getInstanceTypesFromRequest(ctx, requirements.request(), svc)
+}
+
+// getInstanceTypesFromRequest - Get the instance types that meet a GetInstanceTypesFromInstanceRequirements input,
+// smallest first
+func getInstanceTypesFromRequest(ctx context.Context, input *ec2.GetInstanceTypesFromInstanceRequirementsInput, svc EC2Client) (infos []types.InstanceTypeInfo, err error) {
+	var names []string
+	for {
+		var result *ec2.GetInstanceTypesFromInstanceRequirementsOutput
+		result, err = svc.GetInstanceTypesFromInstanceRequirements(ctx, input)
+		if err != nil {
+			log.Printf("Error getting instance types from requirements: %v", err)
+			return
+		}
+		for _, instanceType := range result.InstanceTypes {
+			names = append(names, aws.ToString(instanceType.InstanceType))
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	log.Printf("Requirements matched %d instance types", len(names))
+
+	// Describe the candidates so they can be ranked by size
+	infos, err = ExpandInstanceTypes(ctx, names, svc)
+	if err != nil {
+		return
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return lessBySize(infos[i], infos[j]) })
+	return
+}
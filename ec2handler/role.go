@@ -0,0 +1,94 @@
+package ec2handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// roleCredentials are the credentials of the roles that have been assumed, by region, role ARN and external ID, so a
+// warm Lambda reuses them until they expire. Each is bound to the STS client of its region.
+var (
+	roleCredentialsMu sync.Mutex
+	roleCredentials   = make(map[string]*aws.CredentialsCache)
+)
+
+// LoadConfig - Load the AWS config for the region (the function's region when empty), with the credentials of the role
+// when roleArn is given. cfg.Region is the region used either way.
+func LoadConfig(ctx context.Context, region string, roleArn string, externalId string) (cfg aws.Config, err error) {
+	if roleArn == "" && externalId != "" {
+		err = fmt.Errorf("ExternalId requires RoleArn")
+		return
+	}
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err = config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		log.Printf("Error loading AWS config: %v", err)
+		return
+	}
+	if roleArn != "" {
+		cfg.Credentials, err = GetRoleCredentials(ctx, cfg.Region, roleArn, externalId, sts.NewFromConfig(cfg))
+	}
+	return
+}
+
+// GetRoleCredentials - Get the credentials of the role, assuming it with the external ID (if any) through the STS
+// client of the region the first time. The credentials are retrieved straight away, so a role that can't be assumed
+// fails here rather than on the first EC2 call.
+func GetRoleCredentials(ctx context.Context, region string, roleArn string, externalId string, svc stscreds.AssumeRoleAPIClient) (credentials *aws.CredentialsCache, err error) {
+	key := region + "|" + roleArn + "|" + externalId
+	roleCredentialsMu.Lock()
+	credentials, ok := roleCredentials[key]
+	if !ok {
+		credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(svc, roleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "InstanceTypAZCheck"
+			if externalId != "" {
+				o.ExternalID = aws.String(externalId)
+			}
+		}))
+		roleCredentials[key] = credentials
+	}
+	roleCredentialsMu.Unlock()
+
+	if _, err = credentials.Retrieve(ctx); err != nil {
+		log.Printf("Error assuming role %v: %v", roleArn, err)
+		err = assumeRoleError(roleArn, externalId, err)
+		return
+	}
+	log.Printf("Assumed role %v", roleArn)
+	return
+}
+
+// resetRoleCredentials - Forget the assumed roles' credentials, so the next call assumes the role again
+func resetRoleCredentials() {
+	roleCredentialsMu.Lock()
+	defer roleCredentialsMu.Unlock()
+	clear(roleCredentials)
+}
+
+// assumeRoleError - the error for a role that couldn't be assumed, saying what to check when access was denied
+func assumeRoleError(roleArn string, externalId string, err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		return fmt.Errorf("can't assume role %v: %w", roleArn, err)
+	}
+	check := "the role's trust policy allows this function's role to assume it"
+	if externalId != "" {
+		check += " with the ExternalId"
+	} else {
+		check += " without an ExternalId"
+	}
+	return fmt.Errorf("access denied assuming role %v, check %s and this function's role allows sts:AssumeRole on it: %s",
+		roleArn, check, apiErr.ErrorMessage())
+}
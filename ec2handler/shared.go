@@ -0,0 +1,96 @@
+package ec2handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+)
+
+// STSClient is an interface that defines the methods used from the sts.Client.
+type STSClient interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// GetCallerAccount - Get the account the checks are made from
+func GetCallerAccount(ctx context.Context, svc STSClient) (account string, err error) {
+	var result *sts.GetCallerIdentityOutput
+	result, err = svc.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		log.Printf("Error getting the caller identity: %v", err)
+		return
+	}
+	account = aws.ToString(result.Account)
+	log.Printf("Caller account: %v", account)
+	return
+}
+
+// SharedSubnets - the subnets owned by another account, i.e. shared with the account through RAM
+func SharedSubnets(subnets []types.Subnet, account string) (shared []types.Subnet) {
+	for _, subnet := range subnets {
+		if owner := aws.ToString(subnet.OwnerId); owner != "" && owner != account {
+			shared = append(shared, subnet)
+		}
+	}
+	return
+}
+
+// CheckSharedSubnetLaunchPermission - DryRun CreateNetworkInterface in each of the shared subnets, returning the
+// subnets the account can put instances in and why it can't use the others. As with DryRunLaunch, errors that aren't
+// about the subnet (e.g. throttling or authorization) fail the check. A participant can't use the owner's security
+// groups, so the security groups should be the participant's own.
+func CheckSharedSubnetLaunchPermission(ctx context.Context, subnets []string, securityGroupIds []string, svc EC2Client) (passed []string, failures map[string]string, err error) {
+	log.Printf("CheckSharedSubnetLaunchPermission(%v, %v)", subnets, securityGroupIds)
+	failures = make(map[string]string)
+	for _, subnet := range subnets {
+		_, createErr := svc.CreateNetworkInterface(ctx, &ec2.CreateNetworkInterfaceInput{
+			SubnetId: aws.String(subnet),
+			Groups:   securityGroupIds,
+			DryRun:   aws.Bool(true),
+		})
+		var apiErr smithy.APIError
+		switch {
+		case createErr == nil:
+			// Only happens if DryRun was ignored, which would have left a network interface behind
+			err = fmt.Errorf("CreateNetworkInterface DryRun in %v created a network interface", subnet)
+			return
+		case !errors.As(createErr, &apiErr):
+			log.Printf("Error creating a network interface in %v: %v", subnet, createErr)
+			err = createErr
+			return
+		case apiErr.ErrorCode() == dryRunOperation:
+			passed = append(passed, subnet)
+		case !isSubnetRejection(apiErr.ErrorCode()):
+			log.Printf("Error creating a network interface in %v: %v", subnet, createErr)
+			err = createErr
+			return
+		default:
+			log.Printf("Can't use shared subnet %v: %v", subnet, createErr)
+			failures[subnet] = fmt.Sprintf("%s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+		}
+	}
+	return
+}
+
+// sharedSubnetFailuresError - the error when the account can't use any of the subnets
+func sharedSubnetFailuresError(account string, failures map[string]string) error {
+	subnets := make([]string, 0, len(failures))
+	for subnet := range failures {
+		subnets = append(subnets, subnet)
+	}
+	sort.Strings(subnets)
+	reasons := make([]string, len(subnets))
+	for i, subnet := range subnets {
+		reasons[i] = fmt.Sprintf("%s (%s)", subnet, failures[subnet])
+	}
+	return fmt.Errorf("account %v can't create network interfaces in any of the shared subnets (check the RAM share and "+
+		"that SecurityGroupIds are the account's own): %s", account, strings.Join(reasons, "; "))
+}
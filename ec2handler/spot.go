@@ -0,0 +1,143 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// GetSpotPlacementScores - Get the spot placement score of each availability zone (by zone ID) in the region for
+// launching the target capacity from the instance types in a single zone
+func GetSpotPlacementScores(ctx context.Context, instanceTypes []string, targetCapacity int, region string, svc EC2Client) (scores map[string]int32, err error) {
+	log.Printf("GetSpotPlacementScores(%v, %d, %v)", instanceTypes, targetCapacity, region)
+	scores = make(map[string]int32)
+	input := &ec2.GetSpotPlacementScoresInput{
+		InstanceTypes:          instanceTypes,
+		TargetCapacity:         aws.Int32(int32(targetCapacity)),
+		SingleAvailabilityZone: aws.Bool(true),
+	}
+	if region != "" {
+		input.RegionNames = []string{region}
+	}
+	for {
+		var result *ec2.GetSpotPlacementScoresOutput
+		result, err = svc.GetSpotPlacementScores(ctx, input)
+		if err != nil {
+			return
+		}
+		for _, score := range result.SpotPlacementScores {
+			if region != "" && aws.ToString(score.Region) != region {
+				continue
+			}
+			scores[aws.ToString(score.AvailabilityZoneId)] = aws.ToInt32(score.Score)
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	log.Printf("Spot placement scores: %v", scores)
+	return
+}
+
+// Selection strategies for ordering the available zones
+const (
+	// SelectionStrategyDefault keeps the order the zones were offered in, or spot placement score order when scored
+	SelectionStrategyDefault = "default"
+	// SelectionStrategySpotPlacementScore puts the highest spot placement score first
+	SelectionStrategySpotPlacementScore = "spot-placement-score"
+	// SelectionStrategyLowestSpotPrice puts the cheapest current spot price first
+	SelectionStrategyLowestSpotPrice = "lowest-spot-price"
+)
+
+// defaultSpotProductDescription - the product description spot prices are looked up for
+const defaultSpotProductDescription = "Linux/UNIX"
+
+// GetSpotPrices - Get the latest spot price of the instance type in each of the availability zones
+func GetSpotPrices(ctx context.Context, instanceType string, productDescription string, zones []string, svc EC2Client) (prices map[string]string, err error) {
+	log.Printf("GetSpotPrices(%v, %v, %v)", instanceType, productDescription, zones)
+	if productDescription == "" {
+		productDescription = defaultSpotProductDescription
+	}
+	prices = make(map[string]string)
+	latest := make(map[string]time.Time)
+	// A start time of now returns the price in effect in each zone
+	input := &ec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:       []types.InstanceType{types.InstanceType(instanceType)},
+		ProductDescriptions: []string{productDescription},
+		StartTime:           aws.Time(time.Now()),
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("availability-zone"),
+				Values: zones,
+			},
+		},
+	}
+	for {
+		var result *ec2.DescribeSpotPriceHistoryOutput
+		result, err = svc.DescribeSpotPriceHistory(ctx, input)
+		if err != nil {
+			return
+		}
+		for _, price := range result.SpotPriceHistory {
+			zone := aws.ToString(price.AvailabilityZone)
+			if timestamp := aws.ToTime(price.Timestamp); timestamp.After(latest[zone]) || prices[zone] == "" {
+				latest[zone] = timestamp
+				prices[zone] = aws.ToString(price.SpotPrice)
+			}
+		}
+		if aws.ToString(result.NextToken) == "" {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	log.Printf("Spot prices: %v", prices)
+	return
+}
+
+// OrderZones - Sort the zones in place for the selection strategy. Zones without a score or price go last, and with no
+// zones there is nothing to order.
+func OrderZones(zones []string, strategy string, scores map[string]int32, prices map[string]string) error {
+	if len(zones) == 0 {
+		return nil
+	}
+	switch strategy {
+	case "", SelectionStrategyDefault:
+		if scores == nil {
+			return nil
+		}
+	case SelectionStrategySpotPlacementScore:
+		if scores == nil {
+			return fmt.Errorf("SelectionStrategy %s requires SpotTargetCapacity", strategy)
+		}
+	case SelectionStrategyLowestSpotPrice:
+		price := func(zone string) float64 {
+			if value, err := strconv.ParseFloat(prices[zone], 64); err == nil {
+				return value
+			}
+			return math.Inf(1)
+		}
+		sort.SliceStable(zones, func(i, j int) bool {
+			if a, b := price(zones[i]), price(zones[j]); a != b {
+				return a < b
+			}
+			return scores[zones[i]] > scores[zones[j]]
+		})
+		return nil
+	default:
+		return fmt.Errorf("unsupported SelectionStrategy %q (use %s, %s or %s)", strategy, SelectionStrategyDefault,
+			SelectionStrategySpotPlacementScore, SelectionStrategyLowestSpotPrice)
+	}
+	sort.SliceStable(zones, func(i, j int) bool {
+		return scores[zones[i]] > scores[zones[j]]
+	})
+	return nil
+}
@@ -0,0 +1,62 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// SubnetCheck - the setup shared by the checks of the other services (RDS, ElastiCache and EKS) in a set of subnets
+type SubnetCheck struct {
+	PhysicalResourceId string
+	Subnets            []types.Subnet
+	// Zones are the zones of the subnets, in the order the subnets are described
+	Zones []string
+}
+
+// NewSubnetCheck - Start the check of the resource named label in the subnets of the region, failing if any of them
+// isn't found there
+func NewSubnetCheck(ctx context.Context, label string, subnets []string, region string, svc EC2Client) (check SubnetCheck, err error) {
+	check.PhysicalResourceId = physicalResourceId(ctx, label)
+	check.Subnets, err = GetSubnets(ctx, subnets, svc)
+	if err != nil {
+		return
+	}
+	if err = CheckSubnetsFound(subnets, check.Subnets, region); err != nil {
+		return
+	}
+	for _, subnet := range check.Subnets {
+		if zone := aws.ToString(subnet.AvailabilityZone); !slices.Contains(check.Zones, zone) {
+			check.Zones = append(check.Zones, zone)
+		}
+	}
+	return
+}
+
+// Available - the subnets in the zones available is true for, and those zones, in the order the subnets are described
+func (c SubnetCheck) Available(available func(zone string) bool) (subnets []string, zones []string) {
+	for _, subnet := range c.Subnets {
+		zone := aws.ToString(subnet.AvailabilityZone)
+		if !available(zone) {
+			continue
+		}
+		subnets = append(subnets, aws.ToString(subnet.SubnetId))
+		if !slices.Contains(zones, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	return
+}
+
+// physicalResourceId - the physical resource ID of a check of label for the Lambda request in the context
+func physicalResourceId(ctx context.Context, label string) string {
+	var requestID string
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		requestID = lc.AwsRequestID
+	}
+	return fmt.Sprintf("InstanceTypAZCheck-%v-%v", label, requestID)
+}
@@ -0,0 +1,116 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Instance tenancies
+const (
+	TenancyDefault   = string(types.TenancyDefault)
+	TenancyDedicated = string(types.TenancyDedicated)
+	TenancyHost      = string(types.TenancyHost)
+)
+
+// validateTenancy - Check the tenancy is one EC2 has
+func validateTenancy(tenancy string) error {
+	switch tenancy {
+	case "", TenancyDefault, TenancyDedicated, TenancyHost:
+		return nil
+	}
+	return fmt.Errorf("unknown Tenancy %q (use %s, %s or %s)", tenancy, TenancyDefault, TenancyDedicated, TenancyHost)
+}
+
+// GetVpcTenancy - Get the instance tenancy the subnets' VPCs force, dedicated if any of them has dedicated tenancy
+func GetVpcTenancy(ctx context.Context, subnets []types.Subnet, svc EC2Client) (tenancy string, err error) {
+	tenancy = TenancyDefault
+	var vpcIds []string
+	for _, subnet := range subnets {
+		if vpcId := aws.ToString(subnet.VpcId); vpcId != "" && !slices.Contains(vpcIds, vpcId) {
+			vpcIds = append(vpcIds, vpcId)
+		}
+	}
+	if len(vpcIds) == 0 {
+		return
+	}
+	var result *ec2.DescribeVpcsOutput
+	result, err = svc.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: vpcIds})
+	if err != nil {
+		log.Printf("Error describing VPCs: %v", err)
+		return
+	}
+	for _, vpc := range result.Vpcs {
+		if vpc.InstanceTenancy == types.TenancyDedicated {
+			log.Printf("VPC %v has dedicated tenancy", aws.ToString(vpc.VpcId))
+			tenancy = TenancyDedicated
+		}
+	}
+	return
+}
+
+// CheckTenancyCompatibility - Check the instance type can run with the tenancy, returning the reason if it can't.
+// DescribeInstanceTypes only reports Dedicated Host support, so only host tenancy is checked; whether a type can run
+// as a Dedicated Instance is left to the offerings and the DryRun launch.
+func CheckTenancyCompatibility(tenancy string, info types.InstanceTypeInfo) error {
+	if tenancy != TenancyHost {
+		return nil
+	}
+	if !aws.ToBool(info.DedicatedHostsSupported) {
+		return fmt.Errorf("%s does not support %s tenancy", info.InstanceType, tenancy)
+	}
+	return nil
+}
+
+// GetAvailableHosts - Get the available Dedicated Hosts in each of the zones (name to zone ID, matched by zone ID)
+// with capacity for another instance of the type, most capacity first
+func GetAvailableHosts(ctx context.Context, instanceType string, zoneIds map[string]string, svc EC2Client) (hosts map[string][]string, err error) {
+	log.Printf("GetAvailableHosts(%v, %v)", instanceType, zoneIds)
+	hosts = make(map[string][]string)
+	capacity := make(map[string]int32)
+	names := zoneNames(zoneIds)
+	input := &ec2.DescribeHostsInput{
+		Filter: []types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(types.AllocationStateAvailable)},
+			},
+		},
+	}
+	for {
+		var result *ec2.DescribeHostsOutput
+		result, err = svc.DescribeHosts(ctx, input)
+		if err != nil {
+			log.Printf("Error describing hosts: %v", err)
+			return
+		}
+		for _, host := range result.Hosts {
+			zone, ok := names[aws.ToString(host.AvailabilityZoneId)]
+			if !ok || host.AvailableCapacity == nil {
+				continue
+			}
+			for _, available := range host.AvailableCapacity.AvailableInstanceCapacity {
+				if aws.ToString(available.InstanceType) != instanceType || aws.ToInt32(available.AvailableCapacity) <= 0 {
+					continue
+				}
+				hostId := aws.ToString(host.HostId)
+				hosts[zone] = append(hosts[zone], hostId)
+				capacity[hostId] = aws.ToInt32(available.AvailableCapacity)
+			}
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	for _, ids := range hosts {
+		slices.SortStableFunc(ids, func(a, b string) int { return int(capacity[b] - capacity[a]) })
+	}
+	log.Printf("Available hosts: %v", hosts)
+	return
+}
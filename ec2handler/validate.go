@@ -0,0 +1,100 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// maxSuggestionDistance - the largest edit distance that is still offered as a suggestion
+const maxSuggestionDistance = 3
+
+// isInstanceTypePattern - true if the instance type contains glob wildcards
+func isInstanceTypePattern(instanceType string) bool {
+	return strings.ContainsAny(instanceType, "*?")
+}
+
+// ValidateInstanceTypes - Check that the exact (non-pattern) instance types were found by ExpandInstanceTypes. For an
+// unknown type the region's catalogue is searched for the closest name to suggest.
+func ValidateInstanceTypes(ctx context.Context, instanceTypes []string, found []types.InstanceTypeInfo, svc EC2Client) error {
+	known := make(map[string]bool, len(found))
+	for _, info := range found {
+		known[string(info.InstanceType)] = true
+	}
+	for _, instanceType := range instanceTypes {
+		if isInstanceTypePattern(instanceType) || known[instanceType] {
+			continue
+		}
+		log.Printf("Unknown instance type %v", instanceType)
+		catalogue, err := GetInstanceTypeCatalogue(ctx, svc)
+		if err != nil {
+			return err
+		}
+		if suggestion := suggestInstanceType(instanceType, catalogue); suggestion != "" {
+			return fmt.Errorf("unknown instance type %s; did you mean %s?", instanceType, suggestion)
+		}
+		return fmt.Errorf("unknown instance type %s", instanceType)
+	}
+	return nil
+}
+
+// GetInstanceTypeCatalogue - Get the names of all the instance types offered in the region, sorted
+func GetInstanceTypeCatalogue(ctx context.Context, svc EC2Client) (catalogue []string, err error) {
+	input := &ec2.DescribeInstanceTypesInput{}
+	for {
+		var result *ec2.DescribeInstanceTypesOutput
+		result, err = svc.DescribeInstanceTypes(ctx, input)
+		if err != nil {
+			log.Printf("Error describing instance types: %v", err)
+			return
+		}
+		for _, info := range result.InstanceTypes {
+			catalogue = append(catalogue, string(info.InstanceType))
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	sort.Strings(catalogue)
+	return
+}
+
+// suggestInstanceType - the catalogue entry closest to the instance type, or "" if nothing is close
+func suggestInstanceType(instanceType string, catalogue []string) (suggestion string) {
+	best := maxSuggestionDistance + 1
+	for _, candidate := range catalogue {
+		if distance := levenshtein(strings.ToLower(instanceType), candidate); distance < best {
+			best = distance
+			suggestion = candidate
+		}
+	}
+	return
+}
+
+// levenshtein - the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
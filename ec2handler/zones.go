@@ -0,0 +1,123 @@
+package ec2handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/outposts"
+)
+
+// OutpostsClient is an interface that defines the methods used from the outposts.Client.
+type OutpostsClient interface {
+	GetOutpostInstanceTypes(ctx context.Context, params *outposts.GetOutpostInstanceTypesInput, optFns ...func(*outposts.Options)) (*outposts.GetOutpostInstanceTypesOutput, error)
+}
+
+// ZoneTypeOutpost is the ZoneType of a subnet on an Outpost, whose zone is the Outpost's parent availability zone
+const ZoneTypeOutpost = "outpost"
+
+// SubnetZone - where a subnet is
+type SubnetZone struct {
+	ZoneName string
+	// ZoneType is availability-zone, local-zone, wavelength-zone or outpost
+	ZoneType string
+	// ParentZoneName is the availability zone a Local Zone, Wavelength Zone or Outpost is anchored to
+	ParentZoneName string `json:",omitempty"`
+	OutpostArn     string `json:",omitempty"`
+}
+
+// GetSubnetZones - Get the type and parent zone of the zone each subnet is in. All zones are described, so Local and
+// Wavelength Zones are found whether or not the account has opted in to them.
+func GetSubnetZones(ctx context.Context, subnets []types.Subnet, svc EC2Client) (zones map[string]SubnetZone, err error) {
+	var names []string
+	for _, subnet := range subnets {
+		if name := aws.ToString(subnet.AvailabilityZone); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var result *ec2.DescribeAvailabilityZonesOutput
+	result, err = svc.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		AllAvailabilityZones: aws.Bool(true),
+		ZoneNames:            names,
+	})
+	if err != nil {
+		log.Printf("Error describing availability zones: %v", err)
+		return
+	}
+	byName := make(map[string]types.AvailabilityZone, len(result.AvailabilityZones))
+	for _, zone := range result.AvailabilityZones {
+		byName[aws.ToString(zone.ZoneName)] = zone
+	}
+
+	zones = make(map[string]SubnetZone, len(subnets))
+	for _, subnet := range subnets {
+		name := aws.ToString(subnet.AvailabilityZone)
+		zone := SubnetZone{
+			ZoneName:       name,
+			ZoneType:       aws.ToString(byName[name].ZoneType),
+			ParentZoneName: aws.ToString(byName[name].ParentZoneName),
+		}
+		if outpostArn := aws.ToString(subnet.OutpostArn); outpostArn != "" {
+			zone.ZoneType = ZoneTypeOutpost
+			zone.ParentZoneName = name
+			zone.OutpostArn = outpostArn
+		}
+		zones[aws.ToString(subnet.SubnetId)] = zone
+	}
+	log.Printf("Subnet zones: %v", zones)
+	return
+}
+
+// GetZoneIds - Get the zone IDs of the zones, in order. Zone names are mapped to zones per account, so the client
+// must be for the account the names came from.
+func GetZoneIds(ctx context.Context, zones []string, svc EC2Client) (zoneIds []string, err error) {
+	var result *ec2.DescribeAvailabilityZonesOutput
+	result, err = svc.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		AllAvailabilityZones: aws.Bool(true),
+		ZoneNames:            zones,
+	})
+	if err != nil {
+		log.Printf("Error describing availability zones: %v", err)
+		return
+	}
+	byName := make(map[string]string, len(result.AvailabilityZones))
+	for _, zone := range result.AvailabilityZones {
+		byName[aws.ToString(zone.ZoneName)] = aws.ToString(zone.ZoneId)
+	}
+	for _, zone := range zones {
+		zoneId, ok := byName[zone]
+		if !ok {
+			return nil, fmt.Errorf("zone %v not found", zone)
+		}
+		zoneIds = append(zoneIds, zoneId)
+	}
+	log.Printf("Zone IDs of %v: %v", zones, zoneIds)
+	return
+}
+
+// GetOutpostInstanceTypes - Get the instance types the Outpost has capacity configured for. The regional offerings
+// don't apply to an Outpost.
+func GetOutpostInstanceTypes(ctx context.Context, outpostArn string, svc OutpostsClient) (instanceTypes []string, err error) {
+	input := &outposts.GetOutpostInstanceTypesInput{OutpostId: aws.String(outpostArn)}
+	for {
+		var result *outposts.GetOutpostInstanceTypesOutput
+		result, err = svc.GetOutpostInstanceTypes(ctx, input)
+		if err != nil {
+			log.Printf("Error getting the instance types of Outpost %v: %v", outpostArn, err)
+			return
+		}
+		for _, item := range result.InstanceTypes {
+			instanceTypes = append(instanceTypes, aws.ToString(item.InstanceType))
+		}
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	log.Printf("Outpost %v instance types: %v", outpostArn, instanceTypes)
+	return
+}
@@ -0,0 +1,147 @@
+package ekshandler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+// MockEKSClient is a mock implementation of the EKSClient interface.
+type MockEKSClient struct {
+	mockDescribeCluster func(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+}
+
+func (m *MockEKSClient) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	return m.mockDescribeCluster(ctx, params, optFns...)
+}
+
+// MockEC2Client is a mock of the subnet and offering lookups; the other EC2Client methods are not used.
+type MockEC2Client struct {
+	ec2handler.EC2Client
+	mockDescribeSubnets               func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	mockDescribeInstanceTypeOfferings func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error)
+}
+
+func (m *MockEC2Client) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return m.mockDescribeSubnets(ctx, params, optFns...)
+}
+
+func (m *MockEC2Client) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	return m.mockDescribeInstanceTypeOfferings(ctx, params, optFns...)
+}
+
+func TestGetNodeGroupAvailabilityZones(t *testing.T) {
+	subnets := map[string]types.Subnet{
+		"subnet-a": {SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a"), AvailabilityZoneId: aws.String("use1-az6")},
+		"subnet-b": {SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b"), AvailabilityZoneId: aws.String("use1-az1")},
+		"subnet-e": {SubnetId: aws.String("subnet-e"), AvailabilityZone: aws.String("us-east-1e"), AvailabilityZoneId: aws.String("use1-az3")},
+	}
+	offered := map[string][]string{
+		"t3.large":  {"us-east-1a", "us-east-1b", "us-east-1e"},
+		"t4g.large": {"us-east-1a", "us-east-1b"},
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeSubnets: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			output := &ec2.DescribeSubnetsOutput{}
+			for _, id := range params.Filters[0].Values {
+				output.Subnets = append(output.Subnets, subnets[id])
+			}
+			return output, nil
+		},
+		mockDescribeInstanceTypeOfferings: func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+			output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+			for _, instanceType := range params.Filters[0].Values {
+				for _, zone := range offered[instanceType] {
+					output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, types.InstanceTypeOffering{
+						InstanceType: types.InstanceType(instanceType),
+						Location:     aws.String(zone),
+					})
+				}
+			}
+			return output, nil
+		},
+	}
+	mockEKSClient := &MockEKSClient{
+		mockDescribeCluster: func(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+			if aws.ToString(params.Name) != "apps" {
+				return nil, &ekstypes.ResourceNotFoundException{Message: aws.String("No cluster found")}
+			}
+			return &eks.DescribeClusterOutput{
+				Cluster: &ekstypes.Cluster{
+					ResourcesVpcConfig: &ekstypes.VpcConfigResponse{SubnetIds: []string{"subnet-a", "subnet-b", "subnet-e"}},
+				},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   NodeGroupCheckInput
+		want    NodeGroupCheckResult
+		wantErr bool
+	}{
+		{
+			name:  "Cluster subnets",
+			input: NodeGroupCheckInput{ClusterName: "apps", InstanceTypes: []string{"t3.large", "t4g.large"}},
+			want: NodeGroupCheckResult{
+				PhysicalResourceId:           "InstanceTypAZCheck-apps-",
+				AvailableZones:               []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:             []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:                "subnet-a",
+				FirstAZ:                      "us-east-1a",
+				MissingInstanceTypes:         map[string][]string{"us-east-1e": {"t4g.large"}},
+				UnsupportedControlPlaneZones: []string{"us-east-1e"},
+			},
+		},
+		{
+			name:  "Given subnets",
+			input: NodeGroupCheckInput{ClusterName: "apps", InstanceTypes: []string{"t3.large"}, Subnets: []string{"subnet-b", "subnet-e"}},
+			want: NodeGroupCheckResult{
+				PhysicalResourceId:           "InstanceTypAZCheck-apps-",
+				AvailableZones:               []string{"us-east-1b", "us-east-1e"},
+				AvailableSubnets:             []string{"subnet-b", "subnet-e"},
+				FirstSubnetId:                "subnet-b",
+				FirstAZ:                      "us-east-1b",
+				MissingInstanceTypes:         map[string][]string{},
+				UnsupportedControlPlaneZones: []string{"us-east-1e"},
+			},
+		},
+		{
+			name:    "No zone offers every type",
+			input:   NodeGroupCheckInput{ClusterName: "apps", InstanceTypes: []string{"t4g.large"}, Subnets: []string{"subnet-e"}},
+			wantErr: true,
+		},
+		{
+			name:    "Cluster not found",
+			input:   NodeGroupCheckInput{ClusterName: "missing", InstanceTypes: []string{"t3.large"}},
+			wantErr: true,
+		},
+		{
+			name:    "Subnet in another region",
+			input:   NodeGroupCheckInput{ClusterName: "apps", InstanceTypes: []string{"t3.large"}, Subnets: []string{"subnet-b", "subnet-0eu"}, Region: "us-east-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getNodeGroupAvailabilityZones(context.Background(), tt.input, mockEKSClient, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getNodeGroupAvailabilityZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getNodeGroupAvailabilityZones() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
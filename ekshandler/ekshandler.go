@@ -0,0 +1,203 @@
+package ekshandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// EKSClient is an interface that defines the methods used from the eks.Client.
+type EKSClient interface {
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+}
+
+// ModeEKSNodeGroup checks the subnets of an EKS managed node group
+const ModeEKSNodeGroup = "EKSNodeGroup"
+
+// unsupportedControlPlaneZoneIds are the zone IDs EKS does not place control plane network interfaces in, as listed in
+// the subnet requirements of https://docs.aws.amazon.com/eks/latest/userguide/network-reqs.html. There's no API for
+// them, so this is a static copy of that list and goes stale if AWS changes it.
+var unsupportedControlPlaneZoneIds = map[string]bool{
+	"use1-az3": true,
+	"usw1-az2": true,
+	"cac1-az3": true,
+}
+
+// NodeGroupCheckInput - the parameters of a node group subnet check
+type NodeGroupCheckInput struct {
+	ClusterName   string
+	InstanceTypes []string
+	// Subnets are the node group's subnets, the cluster's subnets when not given
+	Subnets []string
+	// Region is the region the subnets are in, the function's region when not given
+	Region string
+	// RoleArn and ExternalId are the role in the subnets' account to make the checks with (optional)
+	RoleArn    string
+	ExternalId string
+}
+
+// NodeGroupCheckResult - the outcome of a node group subnet check
+type NodeGroupCheckResult struct {
+	PhysicalResourceId string
+	// AvailableZones and AvailableSubnets are where every instance type is offered
+	AvailableZones   []string
+	AvailableSubnets []string
+	FirstSubnetId    string
+	FirstAZ          string
+	// MissingInstanceTypes are the instance types not offered in each of the other zones
+	MissingInstanceTypes map[string][]string
+	// UnsupportedControlPlaneZones are the zones of the subnets EKS can't put control plane network interfaces in
+	UnsupportedControlPlaneZones []string
+}
+
+// Data - the custom resource attributes for the result
+func (r NodeGroupCheckResult) Data() (data map[string]interface{}, err error) {
+	missing, err := json.Marshal(r.MissingInstanceTypes)
+	if err != nil {
+		return
+	}
+	data = map[string]interface{}{
+		"AvailableInAZs":             r.AvailableZones,
+		"AvailableInSubnetIds":       r.AvailableSubnets,
+		"SubnetId":                   r.FirstSubnetId,
+		"AZ":                         r.FirstAZ,
+		"MissingInstanceTypesByAZ":   string(missing),
+		"UnsupportedControlPlaneAZs": r.UnsupportedControlPlaneZones,
+	}
+	return
+}
+
+// GetClusterSubnets - Get the subnets of the EKS cluster's VPC configuration
+func GetClusterSubnets(ctx context.Context, clusterName string, svc EKSClient) (subnets []string, err error) {
+	result, err := svc.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		log.Printf("Error describing cluster %v: %v", clusterName, err)
+		return
+	}
+	if result.Cluster == nil || result.Cluster.ResourcesVpcConfig == nil || len(result.Cluster.ResourcesVpcConfig.SubnetIds) == 0 {
+		err = fmt.Errorf("cluster %v has no subnets", clusterName)
+		return
+	}
+	subnets = result.Cluster.ResourcesVpcConfig.SubnetIds
+	log.Printf("Cluster %v subnets: %v", clusterName, subnets)
+	return
+}
+
+// GetNodeGroupAvailabilityZones - Get the zones and subnets every instance type of the node group is offered in
+func GetNodeGroupAvailabilityZones(ctx context.Context, input NodeGroupCheckInput) (result NodeGroupCheckResult, err error) {
+	cfg, err := ec2handler.LoadConfig(ctx, input.Region, input.RoleArn, input.ExternalId)
+	if err != nil {
+		return
+	}
+	input.Region = cfg.Region
+	return getNodeGroupAvailabilityZones(ctx, input, eks.NewFromConfig(cfg), ec2.NewFromConfig(cfg))
+}
+
+// getNodeGroupAvailabilityZones - GetNodeGroupAvailabilityZones using the given clients
+func getNodeGroupAvailabilityZones(ctx context.Context, input NodeGroupCheckInput, svc EKSClient, ec2Svc ec2handler.EC2Client) (result NodeGroupCheckResult, err error) {
+	if len(input.Subnets) == 0 {
+		input.Subnets, err = GetClusterSubnets(ctx, input.ClusterName, svc)
+		if err != nil {
+			return
+		}
+	}
+	check, err := ec2handler.NewSubnetCheck(ctx, input.ClusterName, input.Subnets, input.Region, ec2Svc)
+	if err != nil {
+		return
+	}
+	result.PhysicalResourceId = check.PhysicalResourceId
+	zones := check.Zones
+	for _, subnet := range check.Subnets {
+		zone := aws.ToString(subnet.AvailabilityZone)
+		if unsupportedControlPlaneZoneIds[aws.ToString(subnet.AvailabilityZoneId)] && !slices.Contains(result.UnsupportedControlPlaneZones, zone) {
+			result.UnsupportedControlPlaneZones = append(result.UnsupportedControlPlaneZones, zone)
+		}
+	}
+
+	offerings, err := ec2handler.GetInstanceTypeOfferings(ctx, input.InstanceTypes, zones, ec2Svc)
+	if err != nil {
+		return
+	}
+	offered := make(map[string]map[string]bool)
+	for _, offering := range offerings {
+		zone := aws.ToString(offering.Location)
+		if offered[zone] == nil {
+			offered[zone] = make(map[string]bool)
+		}
+		offered[zone][string(offering.InstanceType)] = true
+	}
+	result.MissingInstanceTypes = make(map[string][]string)
+	for _, zone := range zones {
+		for _, instanceType := range input.InstanceTypes {
+			if !offered[zone][instanceType] {
+				result.MissingInstanceTypes[zone] = append(result.MissingInstanceTypes[zone], instanceType)
+			}
+		}
+	}
+
+	result.AvailableSubnets, result.AvailableZones = check.Available(func(zone string) bool {
+		return len(result.MissingInstanceTypes[zone]) == 0
+	})
+	if len(result.AvailableSubnets) == 0 {
+		err = fmt.Errorf("no zone of the subnets offers all of %v, missing %v", input.InstanceTypes, result.MissingInstanceTypes)
+		return
+	}
+	result.FirstSubnetId = result.AvailableSubnets[0]
+	result.FirstAZ = result.AvailableZones[0]
+	log.Printf("%v are all offered in %v", input.InstanceTypes, result.AvailableSubnets)
+	return
+}
+
+// getNodeGroupCheckInput - Get the node group check input from the custom resource properties
+func getNodeGroupCheckInput(properties map[string]interface{}) (input NodeGroupCheckInput, err error) {
+	input.ClusterName, _ = properties["ClusterName"].(string)
+	if input.ClusterName == "" {
+		err = fmt.Errorf("ClusterName property is required")
+		return
+	}
+	input.InstanceTypes, _ = ec2handler.GetStringListProperty(properties, "InstanceTypes")
+	if instanceType, ok := properties["InstanceType"].(string); ok && instanceType != "" {
+		input.InstanceTypes = append([]string{instanceType}, input.InstanceTypes...)
+	}
+	if len(input.InstanceTypes) == 0 {
+		err = fmt.Errorf("InstanceTypes property is missing or invalid")
+		return
+	}
+	input.Subnets, _ = ec2handler.GetStringListProperty(properties, "Subnets")
+	input.Region, _ = properties["Region"].(string)
+	input.RoleArn, _ = properties["RoleArn"].(string)
+	input.ExternalId, _ = properties["ExternalId"].(string)
+	return
+}
+
+// InstanceTypAZCheck - Lambda function for the EKSNodeGroup mode. Delete requests are answered by
+// ec2handler.InstanceTypAZCheck.
+func InstanceTypAZCheck(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
+	log.Printf("ekshandler.InstanceTypAZCheck(%#v, %#v)", ctx, event)
+
+	input, err := getNodeGroupCheckInput(event.ResourceProperties)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+	result, err := GetNodeGroupAvailabilityZones(ctx, input)
+	if err != nil {
+		log.Printf("Error getting availability zones: %v", err)
+		return "", nil, err
+	}
+	data, err := result.Data()
+	if err != nil {
+		log.Printf("Error building response data: %v", err)
+		return "", nil, err
+	}
+	log.Printf("Returning: %v, %#v", result.PhysicalResourceId, data)
+	return result.PhysicalResourceId, data, nil
+}
@@ -0,0 +1,151 @@
+package elasticachehandler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	ectypes "github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+)
+
+// MockElastiCacheClient is a mock implementation of the ElastiCacheClient interface.
+type MockElastiCacheClient struct {
+	mockDescribeReservedCacheNodesOfferings func(ctx context.Context, params *elasticache.DescribeReservedCacheNodesOfferingsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error)
+}
+
+func (m *MockElastiCacheClient) DescribeReservedCacheNodesOfferings(ctx context.Context, params *elasticache.DescribeReservedCacheNodesOfferingsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error) {
+	return m.mockDescribeReservedCacheNodesOfferings(ctx, params, optFns...)
+}
+
+// MockEC2Client is a mock of the subnet and offering lookups; the other EC2Client methods are not used.
+type MockEC2Client struct {
+	ec2handler.EC2Client
+	mockDescribeSubnets               func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	mockDescribeInstanceTypeOfferings func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error)
+}
+
+func (m *MockEC2Client) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return m.mockDescribeSubnets(ctx, params, optFns...)
+}
+
+func (m *MockEC2Client) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	return m.mockDescribeInstanceTypeOfferings(ctx, params, optFns...)
+}
+
+func TestGetProxyInstanceType(t *testing.T) {
+	tests := []struct {
+		cacheNodeType string
+		want          string
+		wantErr       bool
+	}{
+		{cacheNodeType: "cache.t4g.small", want: "t4g.small"},
+		{cacheNodeType: "cache.r7g.xlarge", want: "r7g.xlarge"},
+		{cacheNodeType: "t4g.small", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cacheNodeType, func(t *testing.T) {
+			got, err := GetProxyInstanceType(tt.cacheNodeType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetProxyInstanceType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetProxyInstanceType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCacheNodeTypeAvailabilityZones(t *testing.T) {
+	offered := map[string][]string{
+		"t4g.small": {"us-east-1a", "us-east-1b"},
+		"r7g.large": {"us-east-1c"},
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeSubnets: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			return &ec2.DescribeSubnetsOutput{
+				Subnets: []types.Subnet{
+					{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+					{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+					{SubnetId: aws.String("subnet-e"), AvailabilityZone: aws.String("us-east-1e")},
+				},
+			}, nil
+		},
+		mockDescribeInstanceTypeOfferings: func(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+			output := &ec2.DescribeInstanceTypeOfferingsOutput{}
+			instanceType := params.Filters[0].Values[0]
+			for _, zone := range offered[instanceType] {
+				output.InstanceTypeOfferings = append(output.InstanceTypeOfferings, types.InstanceTypeOffering{
+					InstanceType: types.InstanceType(instanceType),
+					Location:     aws.String(zone),
+				})
+			}
+			return output, nil
+		},
+	}
+	mockElastiCacheClient := &MockElastiCacheClient{
+		mockDescribeReservedCacheNodesOfferings: func(ctx context.Context, params *elasticache.DescribeReservedCacheNodesOfferingsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error) {
+			output := &elasticache.DescribeReservedCacheNodesOfferingsOutput{}
+			if aws.ToString(params.CacheNodeType) != "cache.m1.huge" {
+				output.ReservedCacheNodesOfferings = []ectypes.ReservedCacheNodesOffering{{CacheNodeType: params.CacheNodeType}}
+			}
+			return output, nil
+		},
+	}
+	subnets := []string{"subnet-a", "subnet-b", "subnet-e"}
+
+	tests := []struct {
+		name    string
+		input   CacheCheckInput
+		want    CacheCheckResult
+		wantErr bool
+	}{
+		{
+			name:  "Offered in two of the zones",
+			input: CacheCheckInput{CacheNodeType: "cache.t4g.small", Subnets: subnets},
+			want: CacheCheckResult{
+				PhysicalResourceId: "InstanceTypAZCheck-cache.t4g.small-",
+				ProxyInstanceType:  "t4g.small",
+				AvailableZones:     []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:   []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:      "subnet-a",
+				FirstAZ:            "us-east-1a",
+			},
+		},
+		{
+			name:    "Not offered in the subnets' zones",
+			input:   CacheCheckInput{CacheNodeType: "cache.r7g.large", Subnets: subnets},
+			wantErr: true,
+		},
+		{
+			name:    "Not offered in the region",
+			input:   CacheCheckInput{CacheNodeType: "cache.m1.huge", Subnets: subnets},
+			wantErr: true,
+		},
+		{
+			name:    "Subnet in another region",
+			input:   CacheCheckInput{CacheNodeType: "cache.t4g.small", Subnets: []string{"subnet-a", "subnet-0eu"}, Region: "us-east-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getCacheNodeTypeAvailabilityZones(context.Background(), tt.input, mockElastiCacheClient, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getCacheNodeTypeAvailabilityZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getCacheNodeTypeAvailabilityZones() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
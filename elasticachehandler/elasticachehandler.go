@@ -0,0 +1,185 @@
+package elasticachehandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+)
+
+// ElastiCacheClient is an interface that defines the methods used from the elasticache.Client.
+type ElastiCacheClient interface {
+	DescribeReservedCacheNodesOfferings(ctx context.Context, params *elasticache.DescribeReservedCacheNodesOfferingsInput, optFns ...func(*elasticache.Options)) (*elasticache.DescribeReservedCacheNodesOfferingsOutput, error)
+}
+
+// ModeElastiCache checks a cache node type instead of an instance type
+const ModeElastiCache = "ElastiCache"
+
+// cacheNodeTypePrefix is the prefix of ElastiCache node types, e.g. cache.t4g.small
+const cacheNodeTypePrefix = "cache."
+
+// CacheCheckInput - the parameters of a cache node type check
+type CacheCheckInput struct {
+	CacheNodeType string
+	// Subnets are the subnets of the cache subnet group
+	Subnets []string
+	// Region is the region the subnets are in, the function's region when not given
+	Region string
+	// RoleArn and ExternalId are the role in the subnets' account to make the checks with (optional)
+	RoleArn    string
+	ExternalId string
+}
+
+// CacheCheckResult - the outcome of a cache node type check
+type CacheCheckResult struct {
+	PhysicalResourceId string
+	// ProxyInstanceType is the EC2 instance type the zones were checked with
+	ProxyInstanceType string
+	AvailableZones    []string
+	AvailableSubnets  []string
+	FirstSubnetId     string
+	FirstAZ           string
+}
+
+// Data - the custom resource attributes for the result
+func (r CacheCheckResult) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"AvailableInAZs":       r.AvailableZones,
+		"AvailableInSubnetIds": r.AvailableSubnets,
+		"SubnetId":             r.FirstSubnetId,
+		"AZ":                   r.FirstAZ,
+		"ProxyInstanceType":    r.ProxyInstanceType,
+	}
+}
+
+// GetProxyInstanceType - Get the EC2 instance type a cache node type is assumed to run on, e.g. t4g.small for
+// cache.t4g.small. This is a naming heuristic: a node type without an EC2 type of the same name (e.g. an old
+// generation EC2 no longer offers) has no proxy, and its zones can't be checked.
+func GetProxyInstanceType(cacheNodeType string) (instanceType string, err error) {
+	if !strings.HasPrefix(cacheNodeType, cacheNodeTypePrefix) {
+		err = fmt.Errorf("%v is not a cache node type (e.g. cache.t4g.small)", cacheNodeType)
+		return
+	}
+	instanceType = strings.TrimPrefix(cacheNodeType, cacheNodeTypePrefix)
+	return
+}
+
+// CheckCacheNodeTypeOffered - Check the cache node type is offered in the region. ElastiCache has no per zone
+// offerings, so this only checks the region, by looking for any reserved node offering of the type (one page is
+// enough to know there is one).
+func CheckCacheNodeTypeOffered(ctx context.Context, cacheNodeType string, svc ElastiCacheClient) error {
+	input := &elasticache.DescribeReservedCacheNodesOfferingsInput{
+		CacheNodeType: aws.String(cacheNodeType),
+		MaxRecords:    aws.Int32(20),
+	}
+	log.Printf("DescribeReservedCacheNodesOfferings input: %#v", input)
+	result, err := svc.DescribeReservedCacheNodesOfferings(ctx, input)
+	if err != nil {
+		log.Printf("Error describing reserved cache node offerings: %v", err)
+		return err
+	}
+	if len(result.ReservedCacheNodesOfferings) == 0 {
+		return fmt.Errorf("cache node type %v is not offered in this region", cacheNodeType)
+	}
+	log.Printf("%v has %d reserved node offerings", cacheNodeType, len(result.ReservedCacheNodesOfferings))
+	return nil
+}
+
+// GetCacheNodeTypeAvailabilityZones - Get the zones and subnets the cache node type can be placed in. ElastiCache
+// only says whether the node type is offered in the region; the zones are inferred from the EC2 offerings of the
+// ProxyInstanceType, a heuristic that assumes ElastiCache has the node type wherever EC2 has its instance type.
+func GetCacheNodeTypeAvailabilityZones(ctx context.Context, input CacheCheckInput) (result CacheCheckResult, err error) {
+	cfg, err := ec2handler.LoadConfig(ctx, input.Region, input.RoleArn, input.ExternalId)
+	if err != nil {
+		return
+	}
+	input.Region = cfg.Region
+	return getCacheNodeTypeAvailabilityZones(ctx, input, elasticache.NewFromConfig(cfg), ec2.NewFromConfig(cfg))
+}
+
+// getCacheNodeTypeAvailabilityZones - GetCacheNodeTypeAvailabilityZones using the given clients. The region is checked
+// with ElastiCache and the zones with the EC2 offerings of the proxy instance type.
+func getCacheNodeTypeAvailabilityZones(ctx context.Context, input CacheCheckInput, svc ElastiCacheClient, ec2Svc ec2handler.EC2Client) (result CacheCheckResult, err error) {
+	result.ProxyInstanceType, err = GetProxyInstanceType(input.CacheNodeType)
+	if err != nil {
+		return
+	}
+	err = CheckCacheNodeTypeOffered(ctx, input.CacheNodeType, svc)
+	if err != nil {
+		return
+	}
+	check, err := ec2handler.NewSubnetCheck(ctx, input.CacheNodeType, input.Subnets, input.Region, ec2Svc)
+	if err != nil {
+		return
+	}
+	result.PhysicalResourceId = check.PhysicalResourceId
+	zones := check.Zones
+
+	offerings, err := ec2handler.GetInstanceTypeOfferings(ctx, []string{result.ProxyInstanceType}, zones, ec2Svc)
+	if err != nil {
+		return
+	}
+	offered := make(map[string]bool)
+	for _, offering := range offerings {
+		offered[aws.ToString(offering.Location)] = true
+	}
+	result.AvailableSubnets, result.AvailableZones = check.Available(func(zone string) bool { return offered[zone] })
+	if len(offerings) == 0 {
+		err = fmt.Errorf("%v is offered in the region, but its zones can't be inferred as EC2 doesn't offer %v in any of %v",
+			input.CacheNodeType, result.ProxyInstanceType, zones)
+		return
+	}
+	if len(result.AvailableSubnets) == 0 {
+		err = fmt.Errorf("%v (checked as %v) is not available in the zones of the subnets %v", input.CacheNodeType, result.ProxyInstanceType, zones)
+		return
+	}
+	result.FirstSubnetId = result.AvailableSubnets[0]
+	result.FirstAZ = result.AvailableZones[0]
+	log.Printf("%v is available in %v", input.CacheNodeType, result.AvailableSubnets)
+	return
+}
+
+// getCacheCheckInput - Get the cache node type check input from the custom resource properties
+func getCacheCheckInput(properties map[string]interface{}) (input CacheCheckInput, err error) {
+	input.CacheNodeType, _ = properties["CacheNodeType"].(string)
+	if input.CacheNodeType == "" {
+		err = fmt.Errorf("CacheNodeType property is required")
+		return
+	}
+	var ok bool
+	input.Subnets, ok = ec2handler.GetStringListProperty(properties, "Subnets")
+	if !ok || len(input.Subnets) == 0 {
+		err = fmt.Errorf("Subnets property is missing or invalid")
+		return
+	}
+	input.Region, _ = properties["Region"].(string)
+	input.RoleArn, _ = properties["RoleArn"].(string)
+	input.ExternalId, _ = properties["ExternalId"].(string)
+	return
+}
+
+// InstanceTypAZCheck - Lambda function for the ElastiCache mode. Delete requests are answered by
+// ec2handler.InstanceTypAZCheck.
+func InstanceTypAZCheck(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
+	log.Printf("elasticachehandler.InstanceTypAZCheck(%#v, %#v)", ctx, event)
+
+	input, err := getCacheCheckInput(event.ResourceProperties)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+	result, err := GetCacheNodeTypeAvailabilityZones(ctx, input)
+	if err != nil {
+		log.Printf("Error getting availability zones: %v", err)
+		return "", nil, err
+	}
+	data := result.Data()
+	log.Printf("Returning: %v, %#v", result.PhysicalResourceId, data)
+	return result.PhysicalResourceId, data, nil
+}
@@ -53,6 +53,39 @@ resource "aws_iam_role_policy_attachment" "lambda_cfn_policy_attachment" {
   policy_arn = "arn:aws:iam::aws:policy/AWSCloudFormationReadOnlyAccess"
 }
 
+resource "aws_iam_role_policy_attachment" "lambda_service_quotas_policy_attachment" {
+  role       = aws_iam_role.lambda_role.name
+  policy_arn = "arn:aws:iam::aws:policy/ServiceQuotasReadOnlyAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_rds_policy_attachment" {
+  role       = aws_iam_role.lambda_role.name
+  policy_arn = "arn:aws:iam::aws:policy/AmazonRDSReadOnlyAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "lambda_elasticache_policy_attachment" {
+  role       = aws_iam_role.lambda_role.name
+  policy_arn = "arn:aws:iam::aws:policy/AmazonElastiCacheReadOnlyAccess"
+}
+
+resource "aws_iam_role_policy" "lambda_eks_policy" {
+  name   = "EKSDescribeCluster"
+  role   = aws_iam_role.lambda_role.id
+  policy = file("${path.module}/eks-policy.json")
+}
+
+resource "aws_iam_role_policy" "lambda_outposts_policy" {
+  name   = "OutpostsGetInstanceTypes"
+  role   = aws_iam_role.lambda_role.id
+  policy = file("${path.module}/outposts-policy.json")
+}
+
+resource "aws_iam_role_policy" "lambda_assume_role_policy" {
+  name   = "AssumeCheckRoles"
+  role   = aws_iam_role.lambda_role.id
+  policy = file("${path.module}/assume-role-policy.json")
+}
+
 resource "null_resource" "build_lambda" {
   provisioner "local-exec" {
     command = <<EOT
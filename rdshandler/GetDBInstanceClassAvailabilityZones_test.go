@@ -0,0 +1,125 @@
+package rdshandler
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+// MockRDSClient is a mock implementation of the RDSClient interface.
+type MockRDSClient struct {
+	mockDescribeOrderableDBInstanceOptions func(ctx context.Context, params *rds.DescribeOrderableDBInstanceOptionsInput, optFns ...func(*rds.Options)) (*rds.DescribeOrderableDBInstanceOptionsOutput, error)
+}
+
+func (m *MockRDSClient) DescribeOrderableDBInstanceOptions(ctx context.Context, params *rds.DescribeOrderableDBInstanceOptionsInput, optFns ...func(*rds.Options)) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+	return m.mockDescribeOrderableDBInstanceOptions(ctx, params, optFns...)
+}
+
+// MockEC2Client is a mock of the subnet lookups; the other EC2Client methods are not used.
+type MockEC2Client struct {
+	ec2handler.EC2Client
+	mockDescribeSubnets func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+}
+
+func (m *MockEC2Client) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return m.mockDescribeSubnets(ctx, params, optFns...)
+}
+
+func TestGetDBInstanceClassAvailabilityZones(t *testing.T) {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+		{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+		{SubnetId: aws.String("subnet-e"), AvailabilityZone: aws.String("us-east-1e")},
+	}
+	mockEC2Client := &MockEC2Client{
+		mockDescribeSubnets: func(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+			output := &ec2.DescribeSubnetsOutput{}
+			for _, subnet := range subnets {
+				if slices.Contains(params.Filters[0].Values, aws.ToString(subnet.SubnetId)) {
+					output.Subnets = append(output.Subnets, subnet)
+				}
+			}
+			return output, nil
+		},
+	}
+	mockRDSClient := &MockRDSClient{
+		mockDescribeOrderableDBInstanceOptions: func(ctx context.Context, params *rds.DescribeOrderableDBInstanceOptionsInput, optFns ...func(*rds.Options)) (*rds.DescribeOrderableDBInstanceOptionsOutput, error) {
+			if !aws.ToBool(params.Vpc) {
+				t.Errorf("DescribeOrderableDBInstanceOptions() Vpc = %v", params.Vpc)
+			}
+			if aws.ToString(params.DBInstanceClass) != "db.r7g.large" {
+				return &rds.DescribeOrderableDBInstanceOptionsOutput{}, nil
+			}
+			if params.Marker == nil {
+				return &rds.DescribeOrderableDBInstanceOptionsOutput{
+					OrderableDBInstanceOptions: []rdstypes.OrderableDBInstanceOption{
+						{AvailabilityZones: []rdstypes.AvailabilityZone{{Name: aws.String("us-east-1b")}, {Name: aws.String("us-east-1c")}}},
+					},
+					Marker: aws.String("page-2"),
+				}, nil
+			}
+			return &rds.DescribeOrderableDBInstanceOptionsOutput{
+				OrderableDBInstanceOptions: []rdstypes.OrderableDBInstanceOption{
+					{AvailabilityZones: []rdstypes.AvailabilityZone{{Name: aws.String("us-east-1a")}, {Name: aws.String("us-east-1b")}}},
+				},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   RDSCheckInput
+		want    RDSCheckResult
+		wantErr bool
+	}{
+		{
+			name:  "Orderable in two of the zones",
+			input: RDSCheckInput{Engine: "postgres", EngineVersion: "16.3", DBInstanceClass: "db.r7g.large", Subnets: []string{"subnet-a", "subnet-b", "subnet-e"}},
+			want: RDSCheckResult{
+				PhysicalResourceId: "InstanceTypAZCheck-db.r7g.large-",
+				AvailableZones:     []string{"us-east-1a", "us-east-1b"},
+				AvailableSubnets:   []string{"subnet-a", "subnet-b"},
+				FirstSubnetId:      "subnet-a",
+				FirstAZ:            "us-east-1a",
+			},
+		},
+		{
+			name:    "Not orderable",
+			input:   RDSCheckInput{Engine: "postgres", DBInstanceClass: "db.x9.large", Subnets: []string{"subnet-a"}},
+			wantErr: true,
+		},
+		{
+			name:    "No subnet in an orderable zone",
+			input:   RDSCheckInput{Engine: "postgres", EngineVersion: "16.3", DBInstanceClass: "db.r7g.large", Subnets: []string{"subnet-e"}},
+			wantErr: true,
+		},
+		{
+			name:    "Subnet in another region",
+			input:   RDSCheckInput{Engine: "postgres", DBInstanceClass: "db.r7g.large", Subnets: []string{"subnet-a", "subnet-0eu"}, Region: "us-east-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDBInstanceClassAvailabilityZones(context.Background(), tt.input, mockRDSClient, mockEC2Client)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDBInstanceClassAvailabilityZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getDBInstanceClassAvailabilityZones() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package rdshandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+
+	"InstanceTypAZCheck/ec2handler"
+	"github.com/aws/aws-lambda-go/cfn"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+)
+
+// RDSClient is an interface that defines the methods used from the rds.Client.
+type RDSClient interface {
+	DescribeOrderableDBInstanceOptions(ctx context.Context, params *rds.DescribeOrderableDBInstanceOptionsInput, optFns ...func(*rds.Options)) (*rds.DescribeOrderableDBInstanceOptionsOutput, error)
+}
+
+// Custom resource modes handled by InstanceTypAZCheck
+const (
+	// ModeRDS checks only the DB instance class
+	ModeRDS = "RDS"
+	// ModeEC2RDS runs the EC2 check in the zones where the DB instance class is also orderable
+	ModeEC2RDS = "EC2+RDS"
+)
+
+// RDSCheckInput - the parameters of a DB instance class check
+type RDSCheckInput struct {
+	Engine string
+	// EngineVersion and LicenseModel are optional, any version or license model counts when they are not given
+	EngineVersion   string
+	LicenseModel    string
+	DBInstanceClass string
+	// Subnets are the subnets the DB instance could be placed in
+	Subnets []string
+	// Region is the region the subnets are in, the function's region when not given
+	Region string
+	// RoleArn and ExternalId are the role in the subnets' account to make the checks with (optional)
+	RoleArn    string
+	ExternalId string
+}
+
+// RDSCheckResult - the outcome of a DB instance class check
+type RDSCheckResult struct {
+	PhysicalResourceId string
+	// AvailableZones and AvailableSubnets are where the DB instance class is orderable
+	AvailableZones   []string
+	AvailableSubnets []string
+	FirstSubnetId    string
+	FirstAZ          string
+}
+
+// Data - the custom resource attributes for the result
+func (r RDSCheckResult) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"AvailableInAZs":       r.AvailableZones,
+		"AvailableInSubnetIds": r.AvailableSubnets,
+		"SubnetId":             r.FirstSubnetId,
+		"AZ":                   r.FirstAZ,
+	}
+}
+
+// GetOrderableZones - Get the zones of the region the DB instance class is orderable in (in a VPC) for the engine,
+// in name order
+func GetOrderableZones(ctx context.Context, input RDSCheckInput, svc RDSClient) (zones []string, err error) {
+	log.Printf("GetOrderableZones(%+v)", input)
+	request := &rds.DescribeOrderableDBInstanceOptionsInput{
+		Engine:          aws.String(input.Engine),
+		DBInstanceClass: aws.String(input.DBInstanceClass),
+		Vpc:             aws.Bool(true),
+	}
+	if input.EngineVersion != "" {
+		request.EngineVersion = aws.String(input.EngineVersion)
+	}
+	if input.LicenseModel != "" {
+		request.LicenseModel = aws.String(input.LicenseModel)
+	}
+
+	orderable := make(map[string]bool)
+	for {
+		var result *rds.DescribeOrderableDBInstanceOptionsOutput
+		result, err = svc.DescribeOrderableDBInstanceOptions(ctx, request)
+		if err != nil {
+			log.Printf("Error describing orderable DB instance options: %v", err)
+			return
+		}
+		for _, option := range result.OrderableDBInstanceOptions {
+			for _, zone := range option.AvailabilityZones {
+				orderable[aws.ToString(zone.Name)] = true
+			}
+		}
+		if result.Marker == nil {
+			break
+		}
+		request.Marker = result.Marker
+	}
+
+	for zone := range orderable {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	if len(zones) == 0 {
+		err = fmt.Errorf("%v is not orderable for %v %v in this region", input.DBInstanceClass, input.Engine, input.EngineVersion)
+		return
+	}
+	log.Printf("%v is orderable in %v", input.DBInstanceClass, zones)
+	return
+}
+
+// GetDBInstanceClassAvailabilityZones - Get the zones and subnets the DB instance class is orderable in
+func GetDBInstanceClassAvailabilityZones(ctx context.Context, input RDSCheckInput) (result RDSCheckResult, err error) {
+	cfg, err := ec2handler.LoadConfig(ctx, input.Region, input.RoleArn, input.ExternalId)
+	if err != nil {
+		return
+	}
+	input.Region = cfg.Region
+	return getDBInstanceClassAvailabilityZones(ctx, input, rds.NewFromConfig(cfg), ec2.NewFromConfig(cfg))
+}
+
+// getDBInstanceClassAvailabilityZones - GetDBInstanceClassAvailabilityZones using the given clients
+func getDBInstanceClassAvailabilityZones(ctx context.Context, input RDSCheckInput, svc RDSClient, ec2Svc ec2handler.EC2Client) (result RDSCheckResult, err error) {
+	zones, err := GetOrderableZones(ctx, input, svc)
+	if err != nil {
+		return
+	}
+	check, err := ec2handler.NewSubnetCheck(ctx, input.DBInstanceClass, input.Subnets, input.Region, ec2Svc)
+	if err != nil {
+		return
+	}
+	result.PhysicalResourceId = check.PhysicalResourceId
+	result.AvailableSubnets, result.AvailableZones = check.Available(func(zone string) bool { return slices.Contains(zones, zone) })
+	if len(result.AvailableSubnets) == 0 {
+		err = fmt.Errorf("none of the subnets %v are in a zone %v is orderable in %v", input.Subnets, input.DBInstanceClass, zones)
+		return
+	}
+	result.FirstSubnetId = result.AvailableSubnets[0]
+	result.FirstAZ = result.AvailableZones[0]
+	log.Printf("%v is orderable in %v", input.DBInstanceClass, result.AvailableSubnets)
+	return
+}
+
+// getRDSCheckInput - Get the DB instance class check input from the custom resource properties
+func getRDSCheckInput(properties map[string]interface{}) (input RDSCheckInput, err error) {
+	input.Engine, _ = properties["Engine"].(string)
+	input.DBInstanceClass, _ = properties["DBInstanceClass"].(string)
+	if input.Engine == "" || input.DBInstanceClass == "" {
+		err = fmt.Errorf("Engine and DBInstanceClass properties are required")
+		return
+	}
+	input.EngineVersion, _ = properties["EngineVersion"].(string)
+	input.LicenseModel, _ = properties["LicenseModel"].(string)
+	input.Subnets, _ = ec2handler.GetStringListProperty(properties, "Subnets")
+	input.Region, _ = properties["Region"].(string)
+	input.RoleArn, _ = properties["RoleArn"].(string)
+	input.ExternalId, _ = properties["ExternalId"].(string)
+	return
+}
+
+// InstanceTypAZCheck - Lambda function for the RDS and EC2+RDS modes. Delete requests are answered by
+// ec2handler.InstanceTypAZCheck.
+func InstanceTypAZCheck(ctx context.Context, event cfn.Event) (string, map[string]interface{}, error) {
+	log.Printf("rdshandler.InstanceTypAZCheck(%#v, %#v)", ctx, event)
+	mode, _ := event.ResourceProperties["Mode"].(string)
+
+	rdsInput, err := getRDSCheckInput(event.ResourceProperties)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+
+	if mode != ModeEC2RDS {
+		if len(rdsInput.Subnets) == 0 {
+			err = fmt.Errorf("Subnets property is missing or invalid")
+			log.Printf("Error: %v", err)
+			return "", nil, err
+		}
+		result, err := GetDBInstanceClassAvailabilityZones(ctx, rdsInput)
+		if err != nil {
+			log.Printf("Error getting availability zones: %v", err)
+			return "", nil, err
+		}
+		data := result.Data()
+		log.Printf("Returning: %v, %#v", result.PhysicalResourceId, data)
+		return result.PhysicalResourceId, data, nil
+	}
+
+	// Run the EC2 check in the zones the DB instance class is orderable in
+	input, err := ec2handler.GetAZCheckInput(event.ResourceProperties)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+	// The orderable zones' names are the role's account's, so they are looked up there and passed on as zone IDs
+	cfg, err := ec2handler.LoadConfig(ctx, input.Region, input.RoleArn, input.ExternalId)
+	if err != nil {
+		log.Printf("Error loading AWS config: %v", err)
+		return "", nil, err
+	}
+	zones, err := GetOrderableZones(ctx, rdsInput, rds.NewFromConfig(cfg))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+	input.ZoneIds, err = ec2handler.GetZoneIds(ctx, zones, ec2.NewFromConfig(cfg))
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", nil, err
+	}
+	result, err := ec2handler.GetTypeAvailabilityZones(ctx, input)
+	if err != nil {
+		log.Printf("Error getting availability zones: %v", err)
+		return "", nil, err
+	}
+	data, err := result.Data()
+	if err != nil {
+		log.Printf("Error building response data: %v", err)
+		return "", nil, err
+	}
+	data["DBInstanceClass"] = rdsInput.DBInstanceClass
+	data["RDSOrderableAZs"] = zones
+
+	log.Printf("Returning: %v, %#v", result.PhysicalResourceId, data)
+	return result.PhysicalResourceId, data, nil
+}